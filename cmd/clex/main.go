@@ -2,38 +2,312 @@ package main
 
 import (
 	"bufio"
+	"bytes"
+	"context"
+	"flag"
 	"fmt"
-	"log"
+	"io"
 	"os"
+	"path/filepath"
 
 	"github.com/denzel-morris/clex/lex"
+	"github.com/denzel-morris/clex/lex/confusable"
+	"github.com/denzel-morris/clex/lex/diag"
+	"github.com/denzel-morris/clex/lex/sarif"
 )
 
+var (
+	sarifPath = flag.String("sarif", "", "write diagnostics as a SARIF 2.1.0 log to `file` (- for stdout)")
+	maxErrors = flag.Int("max-errors", 20, "stop lexing after `n` errors (0 for no limit), except with -check")
+	std       = flag.String("std", "c11", "lex according to `standard` c11 or c23")
+	tabStop   = flag.Int("tabstop", diag.DefaultTabWidth, "show source lines with tab stops every `n` columns")
+	encoding  = flag.String("encoding", "utf-8", "decode sources as `encoding` utf-8, utf-16le, utf-16be, latin1 or windows-1252")
+	files     = flag.Bool("files", false, "lex every file and directory argument concurrently")
+	jobs      = flag.Int("j", 0, "lex up to `n` files at once with -files (0 for one per CPU)")
+	outDir    = flag.String("o", "", "with -files, write each file's lexemes under `dir`")
+	bidi      = flag.String("bidi", "unpaired", "report `which` bidirectional control characters in comments, literals and identifiers: none, unpaired or any")
+	confuse   = flag.Bool("confusables", false, "with -files or -check, report identifiers that are visually confusable with others or mix scripts")
+	check     = flag.Bool("check", false, "lex every file and directory argument without writing lexemes, exiting with status 1 on any error")
+)
+
+var standards = map[string]lex.Standard{
+	"c11": lex.C11,
+	"c23": lex.C23,
+}
+
+var bidiChecks = map[string]lex.BidiCheck{
+	"none":     lex.BidiNone,
+	"unpaired": lex.BidiUnpaired,
+	"any":      lex.BidiAny,
+}
+
+var encodings = map[string]lex.Encoding{
+	"utf-8":        lex.UTF8,
+	"utf-16le":     lex.UTF16LE,
+	"utf-16be":     lex.UTF16BE,
+	"latin1":       lex.Latin1,
+	"windows-1252": lex.Windows1252,
+}
+
 func main() {
-	input, err := os.Open(os.Args[1])
+	flag.Parse()
+
+	standard, ok := standards[*std]
+	if !ok {
+		fmt.Fprintln(os.Stderr, "unknown standard", *std)
+		os.Exit(2)
+	}
+	enc, ok := encodings[*encoding]
+	if !ok {
+		fmt.Fprintln(os.Stderr, "unknown encoding", *encoding)
+		os.Exit(2)
+	}
+	bidiCheck, ok := bidiChecks[*bidi]
+	if !ok {
+		fmt.Fprintln(os.Stderr, "unknown bidi check", *bidi)
+		os.Exit(2)
+	}
+	opts := options(standard, enc, bidiCheck)
+
+	if *files || *check {
+		lexFiles(flag.Args(), enc, opts)
+		return
+	}
+
+	filename := flag.Arg(0)
+	input, err := os.Open(filename)
 	panicErr(err)
 	defer input.Close()
 
-	output, err := os.OpenFile(os.Args[2], os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
+	output, err := os.OpenFile(flag.Arg(1), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
 	panicErr(err)
 	defer output.Close()
 
-	lexer := lex.NewLexer(
-		lex.NewLookaheadLineReader(lex.NewLookaheadReader(bufio.NewReader(input), 4), 4),
-		&LogErrorPolicy{},
-	)
-	lexemelist, err := lexer.Lex()
-	panicErr(err)
+	policy := &lex.CollectingErrorPolicy{}
+	lexer := lex.New(input, append(opts,
+		lex.WithErrorPolicy(policy),
+		lex.WithFileName(filename),
+	)...)
 
-	for _, lexeme := range lexemelist {
+	var lexErr error
+	for lexeme, err := range lexer.All() {
+		if err != nil {
+			lexErr = err
+			break
+		}
 		fmt.Fprintln(output, lexeme)
 	}
+
+	// Diagnostics are rendered once lexing stops, so that the reader has
+	// seen the complete line of each of them.
+	renderer := newRenderer(lexerSource{lexer})
+	for _, d := range policy.Diagnostics() {
+		renderer.Render(d)
+	}
+
+	if lexErr == lex.ErrTooManyErrors {
+		fmt.Fprintln(os.Stderr, "too many errors emitted, stopping now")
+	} else {
+		panicErr(lexErr)
+	}
+
+	if *sarifPath != "" {
+		log := sarif.NewLog()
+		log.Add(filename, policy.Diagnostics()...)
+		panicErr(writeSARIF(*sarifPath, log))
+	}
 }
 
-type LogErrorPolicy struct{}
+// lexFiles lexes the files named by paths, and the C files under any
+// directories among them, on a pool of workers. Diagnostics are rendered in
+// the order of the files as each one is lexed. With -check, any error makes
+// the exit status 1.
+func lexFiles(paths []string, enc lex.Encoding, opts []lex.Option) {
+	var sources []string
+	roots := map[string]string{}
+	for _, path := range paths {
+		files, err := lex.SourceFiles(path)
+		panicErr(err)
+		for _, file := range files {
+			roots[file] = path
+		}
+		sources = append(sources, files...)
+	}
+
+	var outputs map[string]string
+	if *outDir != "" && !*check {
+		var err error
+		if outputs, err = outputPaths(*outDir, sources, roots); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+	}
 
-func (ep LogErrorPolicy) ReportError(message string, line string, position lex.Position) {
-	log.Printf("error:%s: %s\n\t%s", position, message, line)
+	var checker *confusable.Checker
+	if *confuse {
+		checker = confusable.NewChecker()
+	}
+
+	log := sarif.NewLog()
+	confusableErrors := 0
+	summary := lex.LexFilesFunc(context.Background(), sources, *jobs, func(result lex.FileResult) {
+		diagnostics := result.Diagnostics
+		if checker != nil {
+			confusables, err := findConfusables(checker, result)
+			panicErr(err)
+			confusableErrors += countErrors(confusables)
+			diagnostics = append(diagnostics, confusables...)
+		}
+
+		text, _ := io.ReadAll(lex.NewDecoder(bytes.NewReader(result.Source), enc))
+		renderer := newRenderer(diag.SplitLines(text))
+		for _, d := range diagnostics {
+			renderer.Render(d)
+		}
+		log.Add(result.Path, diagnostics...)
+
+		switch {
+		case result.Err == lex.ErrTooManyErrors:
+			fmt.Fprintln(os.Stderr, result.Path+": too many errors emitted, stopping now")
+		case result.Err != nil:
+			fmt.Fprintln(os.Stderr, result.Err)
+		case outputs != nil:
+			panicErr(writeLexemes(outputs[result.Path], result.Lexemes))
+		}
+	}, opts...)
+	summary.Errors += confusableErrors
+
+	fmt.Fprintf(os.Stderr, "lexed %d files (%d bytes, %d lexemes) in %v, %v across workers; %d errors, %d files failed\n",
+		summary.Files, summary.Bytes, summary.Lexemes, summary.Elapsed, summary.Total, summary.Errors, summary.Failed)
+
+	if *sarifPath != "" {
+		panicErr(writeSARIF(*sarifPath, log))
+	}
+	if summary.Failed > 0 || *check && summary.Errors > 0 {
+		os.Exit(1)
+	}
+}
+
+// outputPaths returns where the lexemes of each of sources, found under the
+// arguments in roots, are written under dir: at the source's own path when
+// that is relative and local, and otherwise at its path relative to the
+// directory containing its argument. Two sources written to the same place
+// are an error.
+func outputPaths(dir string, sources []string, roots map[string]string) (map[string]string, error) {
+	outputs := map[string]string{}
+	writers := map[string]string{}
+	for _, source := range sources {
+		rel := filepath.Clean(source)
+		if !filepath.IsLocal(rel) {
+			var err error
+			if rel, err = filepath.Rel(filepath.Dir(roots[source]), source); err != nil {
+				return nil, err
+			}
+			if !filepath.IsLocal(rel) {
+				return nil, fmt.Errorf("%s: not under %s", source, roots[source])
+			}
+		}
+
+		output := filepath.Join(dir, rel+".lexemes")
+		if writer, ok := writers[output]; ok && filepath.Clean(writer) != filepath.Clean(source) {
+			return nil, fmt.Errorf("%s and %s would both be written to %s", writer, source, output)
+		}
+		writers[output] = source
+		outputs[source] = output
+	}
+	return outputs, nil
+}
+
+// findConfusables checks the identifiers of result against those of the
+// files before it, returning the diagnostics found in it. Checking makes
+// them errors.
+func findConfusables(checker *confusable.Checker, result lex.FileResult) ([]lex.Diagnostic, error) {
+	seen := len(checker.Findings())
+	if err := checker.Add(result.Path, result.Lexemes, result.Positions); err != nil {
+		return nil, err
+	}
+
+	var confusables []lex.Diagnostic
+	for _, f := range checker.Findings()[seen:] {
+		d := f.Diagnostic()
+		if *check {
+			d.Severity = lex.SeverityError
+		}
+		confusables = append(confusables, d)
+	}
+	return confusables, nil
+}
+
+func countErrors(ds []lex.Diagnostic) int {
+	n := 0
+	for _, d := range ds {
+		if d.Severity == lex.SeverityError {
+			n++
+		}
+	}
+	return n
+}
+
+// options configures the lexers. Checking makes bidirectional control
+// characters errors, so that they fail the check, and lexes every file to
+// the end, so that no error goes unreported.
+func options(standard lex.Standard, encoding lex.Encoding, bidiCheck lex.BidiCheck) []lex.Option {
+	bidiSeverity, limit := lex.SeverityWarning, *maxErrors
+	if *check {
+		bidiSeverity, limit = lex.SeverityError, 0
+	}
+	return []lex.Option{
+		lex.WithStandard(standard),
+		lex.WithEncoding(encoding),
+		lex.WithMaxErrors(limit),
+		lex.WithBidiCheck(bidiCheck, bidiSeverity),
+	}
+}
+
+// lexerSource gives the renderer the source lines a lexer has read.
+type lexerSource struct {
+	lex.Lexer
+}
+
+func (s lexerSource) SourceLine(line int) (string, bool) {
+	return lex.SourceLine(s.Lexer, line)
+}
+
+func newRenderer(source diag.Source) *diag.Renderer {
+	renderer := diag.NewRenderer(os.Stderr, "", source)
+	renderer.Color = diag.IsTerminal(os.Stderr)
+	renderer.TabWidth = *tabStop
+	return renderer
+}
+
+func writeLexemes(path string, lexemelist []lex.Lexeme) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	for _, lexeme := range lexemelist {
+		fmt.Fprintln(w, lexeme)
+	}
+	return w.Flush()
+}
+
+func writeSARIF(path string, log *sarif.Log) error {
+	var w io.Writer = os.Stdout
+	if path != "-" {
+		f, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	return log.Encode(w)
 }
 
 func panicErr(err error) {
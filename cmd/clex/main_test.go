@@ -0,0 +1,35 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestOutputPathsKeepArgumentDirectories(t *testing.T) {
+	sources := []string{"a/x.c", "b/x.c", "c/sub/x.c"}
+	roots := map[string]string{"a/x.c": "a/x.c", "b/x.c": "b/x.c", "c/sub/x.c": "c"}
+
+	outputs, err := outputPaths("out", sources, roots)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, source := range sources {
+		if expected := filepath.Join("out", source+".lexemes"); outputs[source] != expected {
+			t.Error("Expected", expected, "for", source, "got", outputs[source])
+		}
+	}
+}
+
+func TestOutputPathsRejectCollisions(t *testing.T) {
+	sources := []string{"/p/a/x.c", "/q/a/x.c"}
+	roots := map[string]string{"/p/a/x.c": "/p/a", "/q/a/x.c": "/q/a"}
+
+	if outputs, err := outputPaths("out", sources, roots); err == nil {
+		t.Error("Expected an error for two sources written to the same place, got", outputs)
+	}
+
+	roots = map[string]string{"/p/a/x.c": "/p/a/x.c", "/q/a/x.c": "/q/a"}
+	if outputs, err := outputPaths("out", sources, roots); err != nil || outputs["/p/a/x.c"] != filepath.Join("out", "x.c.lexemes") {
+		t.Error("Expected /p/a/x.c to be written to out/x.c.lexemes, got", outputs, err)
+	}
+}
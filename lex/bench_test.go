@@ -0,0 +1,87 @@
+package lex
+
+import (
+	"bufio"
+	"runtime"
+	"strings"
+	"testing"
+	"unsafe"
+)
+
+// pipelines lexes a source through each way of assembling the lexer.
+var pipelines = map[string]func(src string) ([]Lexeme, error){
+	"lineReader": func(src string) ([]Lexeme, error) {
+		rd := NewLineReader(NewLookaheadReader(bufio.NewReader(strings.NewReader(src)), 4))
+		return NewLexer(rd, discardErrorPolicy{}).Lex()
+	},
+	"lookaheadLineReader": func(src string) ([]Lexeme, error) {
+		rd := NewLookaheadLineReader(NewLookaheadReader(bufio.NewReader(strings.NewReader(src)), 4), 4)
+		return NewLexer(rd, discardErrorPolicy{}).Lex()
+	},
+	"New": func(src string) ([]Lexeme, error) {
+		return New(strings.NewReader(src)).Lex()
+	},
+	"LexString": func(src string) ([]Lexeme, error) {
+		return LexString(src)
+	},
+	"LexBytes": func(src string) ([]Lexeme, error) {
+		return LexBytes(unsafe.Slice(unsafe.StringData(src), len(src)))
+	},
+}
+
+var corpora = map[string]func(lines int) string{
+	"synthetic":   syntheticSource,
+	"punctuation": punctuationSource,
+}
+
+// allocationBudgets is the most allocations per lexeme each pipeline may
+// make lexing the synthetic corpus, within a few percent of what they make
+// today. The reader chains allocate each lexeme's value; the lookahead line
+// reader also boxes a Position into its ring buffers for every rune, about
+// five per lexeme. The string lexers only grow the returned slice.
+var allocationBudgets = map[string]float64{
+	"lineReader":          0.66,
+	"lookaheadLineReader": 5.1,
+	"New":                 5.1,
+	"LexString":           0.003,
+	"LexBytes":            0.003,
+}
+
+func TestAllocationBudget(t *testing.T) {
+	src := syntheticSource(600)
+	for name, lex := range pipelines {
+		lexemelist, err := lex(src)
+		if err != nil {
+			t.Fatal(name, err)
+		}
+
+		allocs := testing.AllocsPerRun(5, func() { lex(src) })
+		if perLexeme := allocs / float64(len(lexemelist)); perLexeme > allocationBudgets[name] {
+			t.Errorf("%s: expected at most %.2f allocations per lexeme, got %.3f", name, allocationBudgets[name], perLexeme)
+		}
+	}
+}
+
+func BenchmarkLex(b *testing.B) {
+	for corpus, generate := range corpora {
+		src := generate(6000)
+		for name, lex := range pipelines {
+			b.Run(corpus+"/"+name, func(b *testing.B) {
+				lexemelist, _ := lex(src)
+				b.SetBytes(int64(len(src)))
+				b.ReportAllocs()
+
+				var before, after runtime.MemStats
+				runtime.ReadMemStats(&before)
+				for i := 0; i < b.N; i++ {
+					lex(src)
+				}
+				runtime.ReadMemStats(&after)
+
+				lexemes := float64(b.N) * float64(len(lexemelist))
+				b.ReportMetric(float64(after.Mallocs-before.Mallocs)/lexemes, "allocs/lexeme")
+				b.ReportMetric(float64(b.Elapsed().Nanoseconds())/lexemes, "ns/lexeme")
+			})
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package lex
+
+import (
+	"fmt"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/denzel-morris/clex/lex/lexemes"
+)
+
+// BidiCheck selects which bidirectional control characters in comments,
+// string literals, character literals and identifiers the lexer reports.
+// Such characters can reorder how the surrounding code is displayed, hiding
+// what the compiler sees (CVE-2021-42574).
+type BidiCheck int
+
+const (
+	// BidiNone reports none of them.
+	BidiNone BidiCheck = iota
+	// BidiUnpaired reports embeddings, overrides and isolates that are
+	// still open at the end of their line or lexeme, which is what it
+	// takes to spill over into the code that follows.
+	BidiUnpaired
+	// BidiAny reports every bidirectional control character.
+	BidiAny
+)
+
+var bidiCheckToName = map[BidiCheck]string{
+	BidiNone:     "none",
+	BidiUnpaired: "unpaired",
+	BidiAny:      "any",
+}
+
+func (c BidiCheck) String() string {
+	return bidiCheckToName[c]
+}
+
+var bidiControlToName = map[rune]string{
+	0x061C: "ARABIC LETTER MARK",
+	0x200E: "LEFT-TO-RIGHT MARK",
+	0x200F: "RIGHT-TO-LEFT MARK",
+	0x202A: "LEFT-TO-RIGHT EMBEDDING",
+	0x202B: "RIGHT-TO-LEFT EMBEDDING",
+	0x202C: "POP DIRECTIONAL FORMATTING",
+	0x202D: "LEFT-TO-RIGHT OVERRIDE",
+	0x202E: "RIGHT-TO-LEFT OVERRIDE",
+	0x2066: "LEFT-TO-RIGHT ISOLATE",
+	0x2067: "RIGHT-TO-LEFT ISOLATE",
+	0x2068: "FIRST STRONG ISOLATE",
+	0x2069: "POP DIRECTIONAL ISOLATE",
+}
+
+func isBidiControl(r rune) bool {
+	return r >= 0x061C && r <= 0x2069 && unicode.Is(unicode.Bidi_Control, r)
+}
+
+func isBidiEmbedding(r rune) bool { return r >= 0x202A && r <= 0x202E && r != 0x202C }
+func isBidiIsolate(r rune) bool   { return r >= 0x2066 && r <= 0x2068 }
+
+var bidiCheckedTypes = map[lexemes.Type]string{
+	lexemes.Comment:       "comment",
+	lexemes.StringLiteral: "string literal",
+	lexemes.CharLiteral:   "character literal",
+	lexemes.Identifier:    "identifier",
+}
+
+// bidiControl is a bidirectional control character written to the lexeme,
+// along with where it was read.
+type bidiControl struct {
+	r        rune
+	position Position
+	line     string
+}
+
+// recordBidiControl remembers the bidirectional control character r just
+// read, to be checked once the lexeme it belongs to is known.
+func (l *lexer) recordBidiControl(r rune) {
+	position := l.stream.Position()
+	position.Column--
+	position.Offset -= utf8.RuneLen(r)
+	l.bidiControls = append(l.bidiControls, bidiControl{r, position, l.stream.Line()})
+}
+
+// checkBidiControls reports the bidirectional control characters of a
+// lexeme of type typ. Embeddings and overrides are closed by U+202C and
+// isolates by U+2069, which also closes whatever was opened inside the
+// isolate; the end of a line closes everything.
+func (l *lexer) checkBidiControls(typ lexemes.Type) {
+	controls := l.bidiControls
+	l.bidiControls = l.bidiControls[:0]
+	kind, ok := bidiCheckedTypes[typ]
+	if !ok || l.bidiCheck == BidiNone {
+		return
+	}
+
+	unpaired := make([]bool, len(controls))
+	var open []int
+	for i, c := range controls {
+		if i > 0 && c.position.Line != controls[i-1].position.Line {
+			open = open[:0]
+		}
+		switch {
+		case isBidiEmbedding(c.r), isBidiIsolate(c.r):
+			unpaired[i] = true
+			open = append(open, i)
+		case c.r == 0x202C:
+			if n := len(open); n > 0 && isBidiEmbedding(controls[open[n-1]].r) {
+				unpaired[open[n-1]] = false
+				open = open[:n-1]
+			}
+		case c.r == 0x2069:
+			isolate := lastIsolate(controls, open)
+			for n := len(open); n > 0 && open[n-1] >= isolate; n = len(open) {
+				unpaired[open[n-1]] = false
+				open = open[:n-1]
+			}
+		}
+	}
+
+	for i, c := range controls {
+		switch {
+		case unpaired[i]:
+			l.reportBidiControl(c, CodeUnpairedBidiControl, fmt.Sprintf("Unterminated bidirectional control character U+%04X (%s) in %s", c.r, bidiControlToName[c.r], kind))
+		case l.bidiCheck == BidiAny:
+			l.reportBidiControl(c, CodeBidiControl, fmt.Sprintf("Bidirectional control character U+%04X (%s) in %s", c.r, bidiControlToName[c.r], kind))
+		}
+	}
+}
+
+// lastIsolate returns the index into controls of the innermost open
+// isolate, or a value past every index when there is none.
+func lastIsolate(controls []bidiControl, open []int) int {
+	for n := len(open) - 1; n >= 0; n-- {
+		if isBidiIsolate(controls[open[n]].r) {
+			return open[n]
+		}
+	}
+	return len(controls)
+}
+
+func (l *lexer) reportBidiControl(c bidiControl, code Code, message string) {
+	end := c.position
+	end.Column++
+	end.Offset += utf8.RuneLen(c.r)
+
+	l.report(Diagnostic{
+		Severity: l.bidiSeverity,
+		Code:     code,
+		File:     l.fileName,
+		Message:  message,
+		Line:     c.line,
+		Start:    c.position,
+		Position: c.position,
+		Fixes:    []Fix{{Description: fmt.Sprintf("Remove U+%04X", c.r), Start: c.position, End: end}},
+	})
+}
@@ -0,0 +1,92 @@
+package lex
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/denzel-morris/clex/lex/lexemes"
+)
+
+type bidiDiagnostic struct {
+	code   Code
+	column int
+}
+
+func TestBidiControls(t *testing.T) {
+	cases := []struct {
+		input    string
+		check    BidiCheck
+		expected []bidiDiagnostic
+	}{
+		{"/* \u202E } \u2066 if (admin) \u2069 \u2066 begin admins only */", BidiUnpaired, []bidiDiagnostic{{CodeUnpairedBidiControl, 4}, {CodeUnpairedBidiControl, 23}}},
+		{"/* \u202E } \u2066 if (admin) \u2069 \u2066 begin admins only */", BidiAny, []bidiDiagnostic{{CodeUnpairedBidiControl, 4}, {CodeBidiControl, 8}, {CodeBidiControl, 21}, {CodeUnpairedBidiControl, 23}}},
+		{"/* \u202E } \u2066 if (admin) \u2069 \u2066 begin admins only */", BidiNone, nil},
+		{"\"user \u202E \u2066// Check if admin\u2069 \u2066\"", BidiUnpaired, []bidiDiagnostic{{CodeUnpairedBidiControl, 7}, {CodeUnpairedBidiControl, 29}}},
+		{"\"\u202Babc \u202C\"", BidiUnpaired, nil},
+		{"\"\u2067\u202Eabc \u2069\"", BidiUnpaired, nil},
+		{"\"\u202E\u2067abc \u202C\"", BidiUnpaired, []bidiDiagnostic{{CodeUnpairedBidiControl, 2}, {CodeUnpairedBidiControl, 3}}},
+		{"'\u202E'", BidiUnpaired, []bidiDiagnostic{{CodeUnpairedBidiControl, 2}}},
+		{"\"\u200F\"", BidiUnpaired, nil},
+		{"\"\u200F\"", BidiAny, []bidiDiagnostic{{CodeBidiControl, 2}}},
+		{"// \u202E", BidiUnpaired, []bidiDiagnostic{{CodeUnpairedBidiControl, 4}}},
+		{"/* \u202E\n \u202C */", BidiUnpaired, []bidiDiagnostic{{CodeUnpairedBidiControl, 4}}},
+		{"\u202E", BidiAny, []bidiDiagnostic{{CodeUnpairedBidiControl, 1}}},
+		{"int a\u202Eb = 1;", BidiUnpaired, []bidiDiagnostic{{CodeUnpairedBidiControl, 6}}},
+		{"a\u202Eb\u202C", BidiUnpaired, nil},
+	}
+
+	for _, c := range cases {
+		policy := &CollectingErrorPolicy{}
+		New(strings.NewReader(c.input), WithErrorPolicy(policy), WithBidiCheck(c.check, SeverityWarning)).Lex()
+
+		var actual []bidiDiagnostic
+		for _, d := range policy.Diagnostics() {
+			if d.Code == CodeBidiControl || d.Code == CodeUnpairedBidiControl {
+				actual = append(actual, bidiDiagnostic{d.Code, d.Position.Column})
+			}
+		}
+		if len(actual) != len(c.expected) {
+			t.Error("Expected", c.expected, "for", c.input, "with", c.check, "got", actual)
+			continue
+		}
+		for i := range actual {
+			if actual[i] != c.expected[i] {
+				t.Error("Expected", c.expected, "for", c.input, "with", c.check, "got", actual)
+				break
+			}
+		}
+	}
+}
+
+func TestBidiControlDiagnostic(t *testing.T) {
+	policy := &CollectingErrorPolicy{}
+	input := "int x;\n\"a\u202Eb\";"
+	lexemelist, _ := New(strings.NewReader(input), WithErrorPolicy(policy), WithBidiCheck(BidiUnpaired, SeverityError)).Lex()
+	if lexemelist[5] != (Lexeme{lexemes.StringLiteral, "\"a\u202Eb\""}) {
+		t.Error("Expected the string literal to be kept, got", lexemelist[5])
+	}
+
+	ds := policy.Diagnostics()
+	if len(ds) != 1 {
+		t.Fatal("Expected one diagnostic, got", ds)
+	}
+	d := ds[0]
+	expected := "Unterminated bidirectional control character U+202E (RIGHT-TO-LEFT OVERRIDE) in string literal"
+	if d.Severity != SeverityError || d.Message != expected {
+		t.Error("Expected", SeverityError, expected, "got", d.Severity, d.Message)
+	}
+	if d.Position != (Position{Line: 2, Column: 3, Offset: 9}) || d.Line != "\"a\u202E" {
+		t.Error("Expected the diagnostic at 2:3, offset 9, got", d.Position, d.Line)
+	}
+	if len(d.Fixes) != 1 || d.Fixes[0].End.Offset != 12 || d.Fixes[0].Text != "" {
+		t.Error("Expected a fix removing U+202E, got", d.Fixes)
+	}
+}
+
+func TestBidiControlsWithoutTrivia(t *testing.T) {
+	policy := &CollectingErrorPolicy{}
+	New(strings.NewReader("x /* \u202E */ y"), WithErrorPolicy(policy), WithTrivia(false)).Lex()
+	if ds := policy.Diagnostics(); len(ds) != 1 || ds[0].Code != CodeUnpairedBidiControl {
+		t.Error("Expected comments to be checked even when dropped, got", ds)
+	}
+}
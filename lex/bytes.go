@@ -0,0 +1,33 @@
+package lex
+
+import "unsafe"
+
+// LexString lexes src in one pass, decoding runes directly from the string.
+// Lexeme values are substrings of src, so lexing allocates little beyond
+// the returned slice; only a lexeme in which invalid UTF-8 is replaced with
+// U+FFFD is copied, unless WithRawBytes keeps the bytes as they are.
+// Offsets in reported positions count bytes of the decoded text, which is src
+// itself unless it has a byte order mark or isn't UTF-8; lex with New or
+// LexFiles to map them back with OriginalOffset.
+func LexString(src string, opts ...Option) ([]Lexeme, error) {
+	return newStringLexer(src, makeConfig(opts)).Lex()
+}
+
+// LexBytes is like LexString, except that src isn't copied: lexeme values
+// share its memory, much as strings returned by unsafe.String do, so src must
+// not be modified for as long as any of them is in use.
+func LexBytes(src []byte, opts ...Option) ([]Lexeme, error) {
+	return LexString(unsafe.String(unsafe.SliceData(src), len(src)), opts...)
+}
+
+func newStringLexer(src string, cfg config) *lexer {
+	text, offsets, surrogates := decode(src, cfg)
+	stream := newStringReader(text, cfg.tabWidth)
+	stream.surrogates = surrogates
+	l := newLexer(stream, cfg)
+	l.buf = &windowBuffer{stream: stream}
+	if offsets != nil {
+		l.offsets = offsets
+	}
+	return l
+}
@@ -0,0 +1,67 @@
+package lex
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/denzel-morris/clex/lex/lexemes"
+)
+
+func TestLexStringMatchesReaderChain(t *testing.T) {
+	for _, input := range append(roundTripCorpus(), syntheticSource(60)) {
+		expected, _ := makeLookaheadLexer(input, &EmptyErrorPolicy{}).Lex()
+
+		lexemelist, err := LexString(input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !equalLexemes(lexemelist, expected) {
+			t.Errorf("Expected %v, got %v for %q", expected, lexemelist, input)
+		}
+	}
+}
+
+func TestLexBytesReportsSamePositions(t *testing.T) {
+	input := "int x = 0x;\n\tchar *s = \"\\q\";\n$ 1e+\n"
+
+	expected := &CollectingErrorPolicy{}
+	New(strings.NewReader(input), WithErrorPolicy(expected), WithTabWidth(4)).Lex()
+
+	policy := &CollectingErrorPolicy{}
+	LexBytes([]byte(input), WithErrorPolicy(policy), WithTabWidth(4))
+
+	want, got := expected.Diagnostics(), policy.Diagnostics()
+	if len(got) != len(want) {
+		t.Fatal("Expected", want, "got", got)
+	}
+	for i := range got {
+		if got[i].Position != want[i].Position || got[i].Start != want[i].Start || got[i].Line != want[i].Line {
+			t.Errorf("Expected %+v, got %+v", want[i], got[i])
+		}
+	}
+}
+
+func TestStringReaderReturnsSourceLinesInAnyOrder(t *testing.T) {
+	rd := newStringReader("first\r\nsecond\n\nlast", 1)
+	expected := map[int]string{3: "", 1: "first", 4: "last", 2: "second"}
+	for _, line := range []int{3, 1, 4, 2} {
+		if actual, ok := rd.SourceLine(line); !ok || actual != expected[line] {
+			t.Errorf("Expected line %d to be %q, got %q", line, expected[line], actual)
+		}
+	}
+	if _, ok := rd.SourceLine(5); ok {
+		t.Error("Expected no line 5")
+	}
+}
+
+func TestLexStringReplacesInvalidBytesLikeReaderChain(t *testing.T) {
+	input := "\"a\xffb\" 1.e\xff x\xff+1"
+	expected, _ := New(strings.NewReader(input)).Lex()
+	lexemelist, _ := LexString(input)
+	if !equalLexemes(lexemelist, expected) {
+		t.Errorf("Expected %q got %q", expected, lexemelist)
+	}
+	if lexemelist[0] != (Lexeme{lexemes.StringLiteral, "\"a\uFFFDb\""}) {
+		t.Error("Expected the invalid byte to be replaced, got", lexemelist[0])
+	}
+}
@@ -0,0 +1,80 @@
+package lex
+
+// Code identifies the kind of a Diagnostic. Codes are stable: the ID of a
+// code never changes once released, so that tools may suppress or track
+// diagnostics across versions.
+type Code int
+
+const (
+	CodeNone Code = iota
+	CodeMissingHexDigits
+	CodeMissingExponentDigits
+	CodeUnterminatedString
+	CodeUnterminatedChar
+	CodeUnrecognizedCharacter
+	CodeUnknownEscape
+	CodeIncompleteUniversalCharacterName
+	CodeExpectedUniversalCharacterName
+	CodeMissingHexEscapeDigits
+	CodeInvalidUTF8
+	CodeInvalidUniversalCharacterName
+	CodeInvalidIdentifierCharacter
+	CodeInvalidIdentifierStart
+	CodeBidiControl
+	CodeUnpairedBidiControl
+	CodeConfusableIdentifier
+	CodeMixedScriptIdentifier
+	CodeBasicCharacterUCN
+	CodeUnterminatedComment
+	CodeUnpairedSurrogate
+	CodeMissingBinaryExponent
+)
+
+type codeInfo struct {
+	id, name, description string
+	severity              Severity
+}
+
+var codeToInfo = map[Code]codeInfo{
+	CodeNone:                             {"CLEX0000", "none", "Unclassified diagnostic", SeverityError},
+	CodeMissingHexDigits:                 {"CLEX0001", "missing-hex-digits", "Hexadecimal constant without digits", SeverityError},
+	CodeMissingExponentDigits:            {"CLEX0002", "missing-exponent-digits", "Exponent part without digits", SeverityError},
+	CodeUnterminatedString:               {"CLEX0003", "unterminated-string", "String literal not terminated before end of line", SeverityError},
+	CodeUnterminatedChar:                 {"CLEX0004", "unterminated-char", "Character literal not terminated before end of line", SeverityError},
+	CodeUnrecognizedCharacter:            {"CLEX0005", "unrecognized-character", "Character that does not begin any token", SeverityError},
+	CodeUnknownEscape:                    {"CLEX0006", "unknown-escape", "Unknown escape sequence", SeverityError},
+	CodeIncompleteUniversalCharacterName: {"CLEX0007", "incomplete-ucn", "Universal character name with too few hexadecimal digits", SeverityError},
+	CodeExpectedUniversalCharacterName:   {"CLEX0008", "expected-ucn", "Backslash in identifier not followed by a universal character name", SeverityError},
+	CodeMissingHexEscapeDigits:           {"CLEX0009", "missing-hex-escape-digits", "Hexadecimal escape sequence without digits", SeverityError},
+	CodeInvalidUTF8:                      {"CLEX0010", "invalid-utf8", "Byte that is not part of a valid UTF-8 sequence", SeverityError},
+	CodeInvalidUniversalCharacterName:    {"CLEX0011", "invalid-ucn", "Universal character name designating a control character, a surrogate or no character", SeverityError},
+	CodeInvalidIdentifierCharacter:       {"CLEX0012", "invalid-identifier-char", "Character not allowed in an identifier", SeverityError},
+	CodeInvalidIdentifierStart:           {"CLEX0013", "invalid-identifier-start", "Character not allowed at the start of an identifier", SeverityError},
+	CodeBidiControl:                      {"CLEX0014", "bidi-control", "Bidirectional control character in a comment, literal or identifier", SeverityWarning},
+	CodeUnpairedBidiControl:              {"CLEX0015", "unpaired-bidi-control", "Bidirectional control character left open at the end of its line, comment, literal or identifier", SeverityWarning},
+	CodeConfusableIdentifier:             {"CLEX0016", "confusable-identifier", "Identifier visually confusable with another one", SeverityWarning},
+	CodeMixedScriptIdentifier:            {"CLEX0017", "mixed-script-identifier", "Identifier mixing scripts that aren't written together", SeverityWarning},
+	CodeBasicCharacterUCN:                {"CLEX0018", "basic-character-ucn", "Universal character name designating a character of the basic source character set", SeverityError},
+	CodeUnterminatedComment:              {"CLEX0019", "unterminated-comment", "Comment not terminated before end of file", SeverityError},
+	CodeUnpairedSurrogate:                {"CLEX0020", "unpaired-surrogate", "UTF-16 surrogate code unit that is not part of a pair", SeverityError},
+	CodeMissingBinaryExponent:            {"CLEX0021", "missing-binary-exponent", "Hexadecimal floating constant without a binary exponent", SeverityError},
+}
+
+// Codes returns every code, ordered by ID.
+func Codes() []Code {
+	codes := make([]Code, 0, len(codeToInfo))
+	for c := CodeNone; ; c++ {
+		if _, ok := codeToInfo[c]; !ok {
+			return codes
+		}
+		codes = append(codes, c)
+	}
+}
+
+func (c Code) ID() string          { return codeToInfo[c].id }
+func (c Code) String() string      { return codeToInfo[c].name }
+func (c Code) Description() string { return codeToInfo[c].description }
+
+// DefaultSeverity returns the severity diagnostics with code c have unless
+// an option such as WithBidiCheck sets another.
+func (c Code) DefaultSeverity() Severity { return codeToInfo[c].severity }
@@ -0,0 +1,119 @@
+package lex
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/denzel-morris/clex/lex/lexemes"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden .lexemes files in testdata")
+
+// conformanceStandards maps each directory of testdata/conformance to the
+// standard its files are lexed with.
+var conformanceStandards = map[string]Standard{
+	"c11": C11,
+	"c23": C23,
+}
+
+// formatLexemes prints lexemes the way cmd/clex does.
+func formatLexemes(lexemelist []Lexeme) string {
+	var sb strings.Builder
+	for _, lexeme := range lexemelist {
+		fmt.Fprintln(&sb, lexeme)
+	}
+	return sb.String()
+}
+
+// formatDiagnostics prints a line per diagnostic after the lexemes, so that
+// the golden files record what was reported along with what was lexed.
+func formatDiagnostics(diagnostics []Diagnostic) string {
+	var sb strings.Builder
+	for _, d := range diagnostics {
+		fmt.Fprintf(&sb, "%s %s %s: %s\n", d.Severity, d.Code.ID(), d.Position, d.Message)
+	}
+	return sb.String()
+}
+
+func TestConformance(t *testing.T) {
+	for dir, standard := range conformanceStandards {
+		paths, err := filepath.Glob(filepath.Join("testdata", "conformance", dir, "*.c"))
+		if err != nil || len(paths) == 0 {
+			t.Fatal("Expected C files in", dir, "got", paths, err)
+		}
+
+		for _, path := range paths {
+			src, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			policy := &CollectingErrorPolicy{}
+			var lexemelist []Lexeme
+			invalid := 0
+			for lexeme, err := range New(bytes.NewReader(src), WithStandard(standard), WithErrorPolicy(policy)).All() {
+				if err != nil {
+					t.Fatal(path, err)
+				}
+				lexemelist = append(lexemelist, lexeme)
+				if lexeme.Is(lexemes.Invalid) {
+					invalid++
+				}
+			}
+			diagnostics := policy.Diagnostics()
+			if len(diagnostics) < invalid {
+				t.Error(path, ": expected a diagnostic for each of", invalid, "invalid lexemes, got", diagnostics)
+			}
+			actual := formatLexemes(lexemelist) + formatDiagnostics(diagnostics)
+
+			golden := strings.TrimSuffix(path, ".c") + ".lexemes"
+			if *update {
+				if err := os.WriteFile(golden, []byte(actual), 0644); err != nil {
+					t.Fatal(err)
+				}
+				continue
+			}
+
+			expected, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err, "(run go test -update to create it)")
+			}
+			if line, ok := firstDifference(string(expected), actual); !ok {
+				t.Errorf("%s: lexemes differ from %s at line %d\n%s", path, golden, line, diffAt(string(expected), actual, line))
+			}
+
+			if fast, _ := LexBytes(src, WithStandard(standard)); formatLexemes(fast) != formatLexemes(lexemelist) {
+				t.Error(path, ": LexBytes disagrees with the reader chain")
+			}
+		}
+	}
+}
+
+// firstDifference returns the first line, counting from 1, at which a and
+// b differ, and true when they are equal.
+func firstDifference(a, b string) (int, bool) {
+	if a == b {
+		return 0, true
+	}
+	as, bs := strings.Split(a, "\n"), strings.Split(b, "\n")
+	for i := range min(len(as), len(bs)) {
+		if as[i] != bs[i] {
+			return i + 1, false
+		}
+	}
+	return min(len(as), len(bs)) + 1, false
+}
+
+func diffAt(expected, actual string, line int) string {
+	context := func(text string) string {
+		lines := strings.Split(text, "\n")
+		from, to := max(line-3, 0), min(line+2, len(lines))
+		return strings.Join(lines[from:to], "\n")
+	}
+	return "expected:\n" + context(expected) + "\ngot:\n" + context(actual)
+}
@@ -0,0 +1,257 @@
+// Package confusable finds identifiers that look alike but are distinct, as
+// `payment` does with a `payment` whose `a` is Cyrillic U+0430, following the
+// confusable detection and mixed-script detection of Unicode Technical
+// Standard #39.
+package confusable
+
+//go:generate go run gen.go
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/denzel-morris/clex/lex"
+	"github.com/denzel-morris/clex/lex/internal/norm"
+	"github.com/denzel-morris/clex/lex/lexemes"
+)
+
+// Skeleton returns the TR39 skeleton of name, with universal character
+// names decoded first: two identifiers are confusable when their skeletons
+// are equal. The skeleton is the NFD of the prototypes of the characters of
+// the NFD of name.
+func Skeleton(name string) string {
+	var b strings.Builder
+	for _, r := range norm.NFD(lex.DecodeUniversalCharacterNames(name)) {
+		if p, ok := prototypes[r]; ok {
+			b.WriteString(p)
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return norm.NFD(b.String())
+}
+
+// Scripts returns the scripts of the letters of name, with universal
+// character names decoded first, in order of appearance. Characters common
+// to many scripts, like digits and `_`, don't count.
+func Scripts(name string) []string {
+	var scripts []string
+	for _, r := range lex.DecodeUniversalCharacterNames(name) {
+		if script := scriptOf(r); script != "" && !slices.Contains(scripts, script) {
+			scripts = append(scripts, script)
+		}
+	}
+	return scripts
+}
+
+// IsMixedScript reports whether name mixes scripts that aren't written
+// together, as Latin and Cyrillic are not but Han and Hiragana are.
+func IsMixedScript(name string) bool {
+	scripts := Scripts(name)
+	if len(scripts) < 2 {
+		return false
+	}
+	for _, writing := range writingSystems {
+		if isSubset(scripts, writing) {
+			return false
+		}
+	}
+	return true
+}
+
+// writingSystems are the sets of scripts TR39 treats as one for
+// mixed-script detection: Japanese, Korean and Chinese with Bopomofo.
+var writingSystems = [][]string{
+	{"Han", "Hiragana", "Katakana"},
+	{"Han", "Hangul"},
+	{"Han", "Bopomofo"},
+}
+
+func isSubset(scripts, of []string) bool {
+	for _, script := range scripts {
+		if !slices.Contains(of, script) {
+			return false
+		}
+	}
+	return true
+}
+
+// scriptRange is a range of characters of the script named name.
+type scriptRange struct {
+	lo, hi rune
+	name   string
+}
+
+// scriptRanges holds the ranges of every script but Common and Inherited,
+// in order.
+var scriptRanges = makeScriptRanges()
+
+func makeScriptRanges() []scriptRange {
+	var ranges []scriptRange
+	add := func(lo, hi, stride rune, name string) {
+		if stride == 1 {
+			ranges = append(ranges, scriptRange{lo, hi, name})
+			return
+		}
+		for r := lo; r <= hi; r += stride {
+			ranges = append(ranges, scriptRange{r, r, name})
+		}
+	}
+
+	for name, table := range unicode.Scripts {
+		if name == "Common" || name == "Inherited" {
+			continue
+		}
+		for _, r := range table.R16 {
+			add(rune(r.Lo), rune(r.Hi), rune(r.Stride), name)
+		}
+		for _, r := range table.R32 {
+			add(rune(r.Lo), rune(r.Hi), rune(r.Stride), name)
+		}
+	}
+	slices.SortFunc(ranges, func(a, b scriptRange) int { return cmp.Compare(a.lo, b.lo) })
+	return ranges
+}
+
+func scriptOf(r rune) string {
+	switch {
+	case r < utf8.RuneSelf && unicode.IsLetter(r):
+		return "Latin"
+	case r < utf8.RuneSelf:
+		return ""
+	}
+	i, found := slices.BinarySearchFunc(scriptRanges, r, func(s scriptRange, r rune) int { return cmp.Compare(s.lo, r) })
+	if !found {
+		i--
+	}
+	if i >= 0 && r <= scriptRanges[i].hi {
+		return scriptRanges[i].name
+	}
+	return ""
+}
+
+type Kind int
+
+const (
+	// KindConfusable is a pair of distinct identifiers with equal skeletons.
+	KindConfusable Kind = iota
+	// KindMixedScript is an identifier mixing scripts.
+	KindMixedScript
+)
+
+var kindToName = map[Kind]string{
+	KindConfusable:  "confusable",
+	KindMixedScript: "mixed-script",
+}
+
+func (k Kind) String() string {
+	return kindToName[k]
+}
+
+// Occurrence is the first place an identifier was seen.
+type Occurrence struct {
+	Name     string
+	File     string
+	Position lex.Position
+}
+
+// Finding is an identifier that can be mistaken for another. For
+// KindConfusable, Other is the earlier identifier it can be mistaken for.
+type Finding struct {
+	Kind       Kind
+	Identifier Occurrence
+	Other      Occurrence
+	Skeleton   string
+	Scripts    []string
+}
+
+// Diagnostic describes the finding as a warning at the identifier.
+func (f Finding) Diagnostic() lex.Diagnostic {
+	id := f.Identifier
+	d := lex.Diagnostic{
+		Severity: lex.SeverityWarning,
+		File:     id.File,
+		Start:    id.Position,
+		Position: id.Position,
+	}
+	_, size := utf8.DecodeLastRuneInString(id.Name)
+	d.Position.Column += utf8.RuneCountInString(id.Name) - 1
+	d.Position.Offset += len(id.Name) - size
+
+	switch f.Kind {
+	case KindConfusable:
+		d.Code = lex.CodeConfusableIdentifier
+		d.Message = fmt.Sprintf("Identifier `%s` is confusable with `%s` at %s", id.Name, f.Other.Name, location(f.Other))
+	case KindMixedScript:
+		d.Code = lex.CodeMixedScriptIdentifier
+		d.Message = fmt.Sprintf("Identifier `%s` mixes the %s scripts", id.Name, strings.Join(f.Scripts, " and "))
+	}
+	return d
+}
+
+func location(o Occurrence) string {
+	if o.File == "" {
+		return o.Position.String()
+	}
+	return o.File + ":" + o.Position.String()
+}
+
+// Checker collects the identifiers of one or more files and finds the ones
+// that can be mistaken for others. Each distinct identifier is checked once,
+// where it first occurs; spellings with the same name are the same identifier.
+type Checker struct {
+	names     map[string]bool
+	skeletons map[string][]Occurrence
+	findings  []Finding
+}
+
+func NewChecker() *Checker {
+	return &Checker{
+		names:     map[string]bool{},
+		skeletons: map[string][]Occurrence{},
+	}
+}
+
+// Add checks the identifiers among lexemelist, lexemes of file, of which
+// positions holds where each one starts as the lexer reported it (e.g. in
+// FileResult.Positions). It checks nothing unless there is a position for
+// every lexeme.
+func (c *Checker) Add(file string, lexemelist []lex.Lexeme, positions []lex.Position) error {
+	if len(positions) != len(lexemelist) {
+		return fmt.Errorf("confusable: %s: %d positions for %d lexemes", file, len(positions), len(lexemelist))
+	}
+	for i, lexeme := range lexemelist {
+		if lexeme.Is(lexemes.Identifier) {
+			c.addIdentifier(Occurrence{lexeme.Value, file, positions[i]})
+		}
+	}
+	return nil
+}
+
+func (c *Checker) addIdentifier(id Occurrence) {
+	name := lex.DecodeUniversalCharacterNames(id.Name)
+	if c.names[name] {
+		return
+	}
+	c.names[name] = true
+
+	if IsMixedScript(id.Name) {
+		c.findings = append(c.findings, Finding{Kind: KindMixedScript, Identifier: id, Scripts: Scripts(id.Name)})
+	}
+
+	skeleton := Skeleton(id.Name)
+	for _, other := range c.skeletons[skeleton] {
+		c.findings = append(c.findings, Finding{Kind: KindConfusable, Identifier: id, Other: other, Skeleton: skeleton})
+	}
+	c.skeletons[skeleton] = append(c.skeletons[skeleton], id)
+}
+
+// Findings returns what was found so far, in the order the identifiers
+// were added.
+func (c *Checker) Findings() []Finding {
+	return c.findings
+}
@@ -0,0 +1,134 @@
+package confusable
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/denzel-morris/clex/lex"
+)
+
+func TestSkeleton(t *testing.T) {
+	cases := []struct {
+		a, b       string
+		confusable bool
+	}{
+		{"payment", "p\u0430yment", true},
+		{"payment", `p\u0430yment`, true},
+		{"scope", "\u0455\u0441\u043E\u0440\u0435", true},
+		{"PAY", "\u0420\u0391\u03A5", true},
+		{"modem", "rnodern", true},
+		{"l1", "Il", true},
+		{"COUNT0", "COUNTO", true},
+		{"PAY", "\uFF30\uFF21\uFF39", true},
+		{"payment", "\U0001D429ayment", true},
+		{"caf\u00E9", "cafe\u0301", true},
+		{"\u00E0", "a\u0300", true},
+		{"\uAC00", "\u1100\u1161", true},
+		{"payment", "payments", false},
+		{"cafe", "caf\u00E9", false},
+	}
+
+	for _, c := range cases {
+		if (Skeleton(c.a) == Skeleton(c.b)) != c.confusable {
+			t.Error("Expected", c.a, "and", c.b, "confusable to be", c.confusable, "got skeletons", Skeleton(c.a), Skeleton(c.b))
+		}
+	}
+}
+
+func TestIsMixedScript(t *testing.T) {
+	cases := []struct {
+		name    string
+		scripts []string
+		mixed   bool
+	}{
+		{"payment_2", []string{"Latin"}, false},
+		{"p\u0430yment", []string{"Latin", "Cyrillic"}, true},
+		{`p\u0430yment`, []string{"Latin", "Cyrillic"}, true},
+		{"\u0441\u0447\u0435\u0442", []string{"Cyrillic"}, false},
+		{"\u03B1\u03B2x", []string{"Greek", "Latin"}, true},
+		{"\u6F22\u5B57\u3072\u3089\u30AB", []string{"Han", "Hiragana", "Katakana"}, false},
+		{"\uD55C\u6F22", []string{"Hangul", "Han"}, false},
+		{"\u3072\uD55C", []string{"Hiragana", "Hangul"}, true},
+		{"_123", nil, false},
+	}
+
+	for _, c := range cases {
+		if scripts := Scripts(c.name); !slices.Equal(scripts, c.scripts) {
+			t.Error("Expected scripts", c.scripts, "for", c.name, "got", scripts)
+		}
+		if mixed := IsMixedScript(c.name); mixed != c.mixed {
+			t.Error("Expected", c.name, "mixed script to be", c.mixed, "got", mixed)
+		}
+	}
+}
+
+func TestChecker(t *testing.T) {
+	checker := NewChecker()
+	if err := checker.Add(lexAll("a.c", "int payment;\nint total = payment;\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := checker.Add(lexAll("b.c", "void f(void) {\n\tp\u0430yment = 0;\n\tp\u0430yment++;\n}\n", lex.WithTrivia(false), lex.WithTabWidth(4))); err != nil {
+		t.Fatal(err)
+	}
+
+	findings := checker.Findings()
+	if len(findings) != 2 {
+		t.Fatal("Expected 2 findings, got", findings)
+	}
+
+	mixed, confusable := findings[0], findings[1]
+	if mixed.Kind != KindMixedScript || mixed.Identifier.Name != "p\u0430yment" || mixed.Identifier.File != "b.c" {
+		t.Error("Expected a mixed-script finding for b.c, got", mixed)
+	}
+	if confusable.Kind != KindConfusable || confusable.Other.Name != "payment" || confusable.Other.File != "a.c" {
+		t.Error("Expected a confusable finding against a.c, got", confusable)
+	}
+	if p := confusable.Identifier.Position; p != (lex.Position{Line: 2, Column: 5, Offset: 16}) {
+		t.Error("Expected the identifier at 2:5, offset 16, got", p)
+	}
+	if p := confusable.Other.Position; p != (lex.Position{Line: 1, Column: 5, Offset: 4}) {
+		t.Error("Expected the other identifier at 1:5, offset 4, got", p)
+	}
+}
+
+func TestCheckerRejectsMissingPositions(t *testing.T) {
+	checker := NewChecker()
+	file, lexemelist, positions := lexAll("a.c", "int payment;\n")
+	if err := checker.Add(file, lexemelist, positions[:1]); err == nil {
+		t.Error("Expected an error for", len(lexemelist), "lexemes with 1 position")
+	}
+}
+
+func TestFindingDiagnostic(t *testing.T) {
+	f := Finding{
+		Kind:       KindConfusable,
+		Identifier: Occurrence{"p\u0430yment", "b.c", lex.Position{Line: 2, Column: 2, Offset: 16}},
+		Other:      Occurrence{"payment", "a.c", lex.Position{Line: 1, Column: 5, Offset: 4}},
+	}
+	d := f.Diagnostic()
+	expected := "Identifier `p\u0430yment` is confusable with `payment` at a.c:1:5"
+	if d.Code != lex.CodeConfusableIdentifier || d.Message != expected {
+		t.Error("Expected", expected, "got", d.Code, d.Message)
+	}
+	if d.Start.Column != 2 || d.Position.Column != 8 || d.Position.Offset != 23 {
+		t.Error("Expected the diagnostic to span columns 2 to 8, got", d.Start, d.Position, d.Position.Offset)
+	}
+
+	f = Finding{Kind: KindMixedScript, Identifier: f.Identifier, Scripts: []string{"Latin", "Cyrillic"}}
+	if d := f.Diagnostic(); d.Code != lex.CodeMixedScriptIdentifier || !strings.Contains(d.Message, "Latin and Cyrillic") {
+		t.Error("Expected a mixed-script diagnostic, got", d)
+	}
+}
+
+func lexAll(file, src string, opts ...lex.Option) (string, []lex.Lexeme, []lex.Position) {
+	var lexemelist []lex.Lexeme
+	var positions []lex.Position
+	l := lex.New(strings.NewReader(src), opts...)
+	for lexeme := range l.All() {
+		position, _ := lex.LexemeStart(l)
+		lexemelist = append(lexemelist, lexeme)
+		positions = append(positions, position)
+	}
+	return file, lexemelist, positions
+}
@@ -0,0 +1,148 @@
+//go:build ignore
+
+// Gen writes tables.go from the confusables.txt data of Unicode Technical
+// Standard #39:
+//
+//	go run gen.go -version 15.0.0
+//
+// The file may be given as a local path instead of being downloaded. The
+// prototypes are normalized with the tables of package norm, which should be
+// generated from the same version first.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"io"
+	"iter"
+	"log"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/denzel-morris/clex/lex/internal/norm"
+)
+
+var (
+	version     = flag.String("version", "15.0.0", "Unicode `version` of the data")
+	confusables = flag.String("confusables", "", "read confusables.txt from `path` rather than unicode.org")
+	output      = flag.String("o", "tables.go", "write the tables to `file`")
+)
+
+func main() {
+	flag.Parse()
+
+	prototypes := readConfusables(open(*confusables, "https://www.unicode.org/Public/security/"+*version+"/confusables.txt"))
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// Code generated by gen.go from Unicode %s data; DO NOT EDIT.\n\n", *version)
+	fmt.Fprintf(&b, "package confusable\n\n")
+	writeMap(&b, "prototypes maps the characters of confusables.txt that are in NFD to the\n// NFD of their prototype.", "prototypes", "string", prototypes, strconv.QuoteToASCII)
+
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(*output, src, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func open(path, url string) io.Reader {
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			log.Fatal(err)
+		}
+		return bytes.NewReader(data)
+	}
+
+	resp, err := http.Get(url)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		log.Fatal(url, ": ", resp.Status)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return bytes.NewReader(data)
+}
+
+// readConfusables reads the prototypes of the characters that are in NFD;
+// since a skeleton is computed from the NFD of an identifier, no other
+// character is ever mapped.
+func readConfusables(r io.Reader) map[rune]string {
+	prototypes := map[rune]string{}
+	for fields := range records(r, ";") {
+		c := parseCodePoint(fields[0])
+		if norm.NFD(string(c)) != string(c) {
+			continue
+		}
+		if prototype := norm.NFD(string(parseCodePoints(fields[1]))); prototype != string(c) {
+			prototypes[c] = prototype
+		}
+	}
+	return prototypes
+}
+
+// records yields the fields of each line of r, ignoring comments and blank
+// lines.
+func records(r io.Reader, sep string) iter.Seq[[]string] {
+	return func(yield func([]string) bool) {
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() {
+			line, _, _ := strings.Cut(strings.TrimPrefix(scanner.Text(), "\uFEFF"), "#")
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			fields := strings.Split(line, sep)
+			for i := range fields {
+				fields[i] = strings.TrimSpace(fields[i])
+			}
+			if !yield(fields) {
+				return
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+func parseCodePoint(s string) rune {
+	c, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return rune(c)
+}
+
+func parseCodePoints(s string) []rune {
+	var rs []rune
+	for _, field := range strings.Fields(s) {
+		rs = append(rs, parseCodePoint(field))
+	}
+	return rs
+}
+
+func writeMap[V any](b *bytes.Buffer, doc, name, typ string, m map[rune]V, value func(V) string) {
+	fmt.Fprintf(b, "// %s\nvar %s = map[rune]%s{\n", doc, name, typ)
+	keys := make([]rune, 0, len(m))
+	for c := range m {
+		keys = append(keys, c)
+	}
+	slices.Sort(keys)
+	for _, c := range keys {
+		fmt.Fprintf(b, "\t0x%04X: %s,\n", c, value(m[c]))
+	}
+	fmt.Fprintf(b, "}\n\n")
+}
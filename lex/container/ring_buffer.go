@@ -5,9 +5,13 @@ import "errors"
 type RingBuffer struct {
 	buffer      []interface{}
 	start, used uint64
+	unbounded   bool
 }
 
-var ErrRingBufferEmpty = errors.New("ring_buffer: empty")
+var (
+	ErrRingBufferEmpty      = errors.New("ring_buffer: empty")
+	ErrRingBufferOutOfRange = errors.New("ring_buffer: index out of range")
+)
 
 func NewRingBuffer(size uint64) *RingBuffer {
 	if size == 0 {
@@ -21,6 +25,18 @@ func NewRingBuffer(size uint64) *RingBuffer {
 	}
 }
 
+// NewUnboundedRingBuffer returns a ring buffer that grows when full instead
+// of overwriting its oldest element.
+func NewUnboundedRingBuffer(size uint64) *RingBuffer {
+	rb := NewRingBuffer(size)
+	rb.unbounded = true
+	return rb
+}
+
+func (rb *RingBuffer) Len() uint64 {
+	return rb.used
+}
+
 func (rb *RingBuffer) Top() (interface{}, error) {
 	if rb.empty() {
 		return nil, ErrRingBufferEmpty
@@ -30,7 +46,21 @@ func (rb *RingBuffer) Top() (interface{}, error) {
 	return rb.buffer[index], nil
 }
 
+// At returns the ith element counting from the oldest.
+func (rb *RingBuffer) At(i uint64) (interface{}, error) {
+	if i >= rb.used {
+		return nil, ErrRingBufferOutOfRange
+	}
+
+	index := (rb.start + i) % uint64(len(rb.buffer))
+	return rb.buffer[index], nil
+}
+
 func (rb *RingBuffer) Push(r interface{}) {
+	if rb.full() && rb.unbounded {
+		rb.grow()
+	}
+
 	if rb.full() {
 		rb.buffer[rb.start] = r
 		rb.start = (rb.start + 1) % uint64(len(rb.buffer))
@@ -49,6 +79,26 @@ func (rb *RingBuffer) Pop() (interface{}, error) {
 	return r, err
 }
 
+// Shift removes and returns the oldest element.
+func (rb *RingBuffer) Shift() (interface{}, error) {
+	r, err := rb.At(0)
+	if err == nil {
+		rb.buffer[rb.start] = nil
+		rb.start = (rb.start + 1) % uint64(len(rb.buffer))
+		rb.used--
+	}
+	return r, err
+}
+
+func (rb *RingBuffer) grow() {
+	buffer := make([]interface{}, 2*len(rb.buffer))
+	for i := uint64(0); i < rb.used; i++ {
+		buffer[i], _ = rb.At(i)
+	}
+	rb.buffer = buffer
+	rb.start = 0
+}
+
 func (rb *RingBuffer) full() bool {
 	return rb.used == uint64(len(rb.buffer))
 }
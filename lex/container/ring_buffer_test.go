@@ -26,3 +26,50 @@ func TestRingBufferErrorsWhenEmpty(t *testing.T) {
 		t.Error("Expected error when popping while empty, got", err)
 	}
 }
+
+func TestUnboundedRingBufferGrows(t *testing.T) {
+	rb := NewUnboundedRingBuffer(2)
+	rb.Push('a')
+	rb.Shift()
+	rb.Push('b')
+	rb.Push('c')
+	rb.Push('d')
+
+	if rb.Len() != 3 {
+		t.Error("Expected length 3 after growing, got", rb.Len())
+	}
+
+	for i, expected := range []rune{'b', 'c', 'd'} {
+		r, _ := rb.At(uint64(i))
+		if r != expected {
+			t.Error("Expected", expected, "at", i, "got", r)
+		}
+	}
+
+	r, _ := rb.Shift()
+	if r != 'b' {
+		t.Error("Expected to shift 'b', got", r)
+	}
+	r, _ = rb.Pop()
+	if r != 'd' {
+		t.Error("Expected to pop 'd', got", r)
+	}
+}
+
+func TestRingBufferAtErrorsOutOfRange(t *testing.T) {
+	rb := NewRingBuffer(2)
+	rb.Push('a')
+	_, err := rb.At(1)
+	if err != ErrRingBufferOutOfRange {
+		t.Error("Expected error when indexing past the end, got", err)
+	}
+}
+
+func BenchmarkRingBufferPushPop(b *testing.B) {
+	rb := NewRingBuffer(4)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		rb.Push(rune('a' + i%26))
+		rb.Pop()
+	}
+}
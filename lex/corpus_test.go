@@ -0,0 +1,54 @@
+package lex
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+)
+
+// syntheticSource generates lines of C resembling preprocessed code, dense
+// with identifiers, keywords, numbers, strings, comments and punctuators.
+func syntheticSource(lines int) string {
+	rnd := rand.New(rand.NewSource(1))
+	identifiers := []string{"i", "count", "buffer_size", "node", "next", "ptr", "__x86_64", "result"}
+	numbers := []string{"0", "42", "0x7fffffff", "1.5e-3", "017", "100UL", ".25f", "0x1p-2"}
+	operators := []string{"+", "-", "*", "/", "%", "<<", ">>", "==", "!=", "&&", "||", "->", "<=", "|="}
+
+	var sb strings.Builder
+	for i := 0; i < lines; i++ {
+		id := func() string { return identifiers[rnd.Intn(len(identifiers))] }
+		switch i % 6 {
+		case 0:
+			fmt.Fprintf(&sb, "static inline int %s_%d(struct %s *%s, unsigned long %s) {\n", id(), i, id(), id(), id())
+		case 1:
+			fmt.Fprintf(&sb, "\t%s = %s %s %s;\n", id(), numbers[rnd.Intn(len(numbers))], operators[rnd.Intn(len(operators))], id())
+		case 2:
+			fmt.Fprintf(&sb, "\tif (%s->%s %s %s) return puts(\"%s: %%d\\n\");\n", id(), id(), operators[rnd.Intn(len(operators))], numbers[rnd.Intn(len(numbers))], id())
+		case 3:
+			fmt.Fprintf(&sb, "\t/* %s %s */ %s[%d] = '%c'; // %s\n", id(), id(), id(), i, 'a'+rune(i%26), id())
+		case 4:
+			fmt.Fprintf(&sb, "\tfor (%s = 0; %s < %s; ++%s) { %s += %s; }\n", id(), id(), id(), id(), id(), numbers[rnd.Intn(len(numbers))])
+		case 5:
+			sb.WriteString("}\n\n")
+		}
+	}
+	return sb.String()
+}
+
+// punctuationSource generates lines of C made up almost entirely of
+// punctuators, including digraphs and the ones that need backing out of.
+func punctuationSource(lines int) string {
+	fragments := []string{
+		"a->b[i]++;", "x<<=y>>=z;", "p&&!q||~r;", "%:%:", "<%<:a:>%>", "f(...);",
+		"s..t", "%:%x", "a+=b-=c*=d/=e%=f;", "a!=b==c<=d>=e?f:g;", "##", "x^y|z&w,v",
+	}
+
+	var sb strings.Builder
+	for i := 0; i < lines; i++ {
+		for j := 0; j < 8; j++ {
+			sb.WriteString(fragments[(i*7+j)%len(fragments)])
+		}
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
@@ -0,0 +1,276 @@
+package lex
+
+import (
+	"bufio"
+	"io"
+	"sort"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+var byteOrderMarks = []struct {
+	bom      string
+	encoding Encoding
+}{
+	{"\xEF\xBB\xBF", UTF8},
+	{"\xFF\xFE", UTF16LE},
+	{"\xFE\xFF", UTF16BE},
+}
+
+// detectBOM returns the index in byteOrderMarks of the byte order mark src
+// starts with, or -1 if there is none.
+func detectBOM(src string) int {
+	for i, m := range byteOrderMarks {
+		if strings.HasPrefix(src, m.bom) {
+			return i
+		}
+	}
+	return -1
+}
+
+// peekBOM is detectBOM for the source read by br.
+func peekBOM(br *bufio.Reader) int {
+	prefix, _ := br.Peek(3)
+	return detectBOM(string(prefix))
+}
+
+// Decoder is an input stage for the reader chain that decodes a source to
+// runes. A byte order mark at the start of the source is stripped and
+// overrides the encoding given to the decoder.
+//
+// Offsets in positions reported by the lexer are in bytes of the decoded,
+// UTF-8 encoded text; OriginalOffset maps them back to the source's bytes.
+type Decoder struct {
+	rd       *bufio.Reader
+	src      io.ReaderAt // the source, when lines can be read back from it
+	encoding Encoding
+	codePage *CodePage
+	started  bool
+
+	// last is the last rune read along with its size in the decoded text
+	// and in the source, for UnreadRune.
+	last            rune
+	lastSize        int
+	lastOrigSize    int
+	unread          bool
+	decoded, offset int
+
+	// pending holds what's left of the rune last decoded by Read.
+	pending []byte
+	buf     [utf8.UTFMax]byte
+
+	segments offsetMap
+}
+
+// offsetMap maps offsets in decoded text to offsets in the source, as a list
+// of segments ordered by offset. An empty map leaves offsets as they are.
+type offsetMap []offsetSegment
+
+// offsetSegment is a run of the source in which every rune takes size bytes
+// in the source for every decodedSize bytes in the decoded text.
+type offsetSegment struct {
+	decoded, original int
+	decodedSize, size int
+}
+
+func NewDecoder(r io.Reader, e Encoding) *Decoder {
+	d := newDecoder(bufio.NewReader(r), config{encoding: e})
+	d.src, _ = r.(io.ReaderAt)
+	return d
+}
+
+// NewCodePageDecoder returns a decoder for a single-byte encoding.
+func NewCodePageDecoder(r io.Reader, cp *CodePage) *Decoder {
+	d := newDecoder(bufio.NewReader(r), config{codePage: cp})
+	d.src, _ = r.(io.ReaderAt)
+	return d
+}
+
+func newDecoder(br *bufio.Reader, cfg config) *Decoder {
+	cp := cfg.codePage
+	if cp == nil {
+		cp = encodingToCodePage[cfg.encoding]
+	}
+	return &Decoder{rd: br, encoding: cfg.encoding, codePage: cp}
+}
+
+func (d *Decoder) ReadRune() (r rune, size int, err error) {
+	if d.unread {
+		d.unread = false
+		d.decoded += d.lastSize
+		d.offset += d.lastOrigSize
+		return d.last, d.lastOrigSize, nil
+	}
+	if !d.started {
+		d.start()
+	}
+
+	switch {
+	case d.encoding == UTF16LE || d.encoding == UTF16BE:
+		r, size, err = d.readUTF16()
+	case d.codePage != nil:
+		r, size, err = d.readCodePage()
+	default:
+		r, size, err = d.readUTF8()
+	}
+	if err != nil {
+		return r, size, err
+	}
+
+	decodedSize := utf8.RuneLen(r)
+	switch {
+	case isEscapedByte(r):
+		decodedSize = 1
+	case decodedSize < 0:
+		decodedSize = len(string(utf8.RuneError))
+	}
+	d.record(decodedSize, size)
+	d.last, d.lastSize, d.lastOrigSize = r, decodedSize, size
+	d.decoded += decodedSize
+	d.offset += size
+	return r, size, nil
+}
+
+// Read reads the decoded text as UTF-8, so that a Decoder can stand in for
+// any reader. Reads shouldn't be mixed with calls to ReadRune.
+func (d *Decoder) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		if len(d.pending) > 0 {
+			c := copy(p[n:], d.pending)
+			d.pending = d.pending[c:]
+			n += c
+			continue
+		}
+
+		r, _, err := d.ReadRune()
+		if err != nil {
+			if n > 0 {
+				return n, nil
+			}
+			return 0, err
+		}
+		if b, ok := unescapeByte(r); ok {
+			d.pending = append(d.buf[:0], b)
+		} else {
+			d.pending = utf8.AppendRune(d.buf[:0], r)
+		}
+	}
+	return n, nil
+}
+
+func (d *Decoder) UnreadRune() error {
+	if d.unread || d.lastOrigSize == 0 {
+		return bufio.ErrInvalidUnreadRune
+	}
+	d.unread = true
+	d.decoded -= d.lastSize
+	d.offset -= d.lastOrigSize
+	return nil
+}
+
+// start strips a byte order mark, which then decides the encoding.
+func (d *Decoder) start() {
+	d.started = true
+	if i := peekBOM(d.rd); i >= 0 {
+		m := byteOrderMarks[i]
+		d.rd.Discard(len(m.bom))
+		d.offset = len(m.bom)
+		d.encoding, d.codePage = m.encoding, nil
+	}
+	d.segments = offsetMap{{decoded: 0, original: d.offset, decodedSize: 1, size: 1}}
+}
+
+func (d *Decoder) readUTF8() (rune, int, error) {
+	r, size, err := d.rd.ReadRune()
+	if err == nil && r == utf8.RuneError && size == 1 {
+		d.rd.UnreadByte()
+		b, _ := d.rd.ReadByte()
+		r = escapeByte(b)
+	}
+	return r, size, err
+}
+
+func (d *Decoder) readCodePage() (rune, int, error) {
+	b, err := d.rd.ReadByte()
+	if err != nil || b < utf8.RuneSelf {
+		return rune(b), 1, err
+	}
+	return d.codePage[b-utf8.RuneSelf], 1, nil
+}
+
+// readUTF16 decodes a code unit, or a surrogate pair. Unpaired surrogates are
+// escaped so that they can be reported, and a trailing odd byte decodes to
+// U+FFFD.
+func (d *Decoder) readUTF16() (rune, int, error) {
+	units, err := d.rd.Peek(4)
+	switch {
+	case len(units) == 0:
+		return 0, 0, err
+	case len(units) == 1:
+		d.rd.Discard(1)
+		return utf8.RuneError, 1, nil
+	}
+
+	r := d.codeUnit(units)
+	if !utf16.IsSurrogate(r) {
+		d.rd.Discard(2)
+		return r, 2, nil
+	}
+	if len(units) == 4 {
+		if pair := utf16.DecodeRune(r, d.codeUnit(units[2:])); pair != utf8.RuneError {
+			d.rd.Discard(4)
+			return pair, 4, nil
+		}
+	}
+	d.rd.Discard(2)
+	return escapeSurrogate(r), 2, nil
+}
+
+func (d *Decoder) codeUnit(b []byte) rune {
+	if d.encoding == UTF16LE {
+		return rune(b[0]) | rune(b[1])<<8
+	}
+	return rune(b[0])<<8 | rune(b[1])
+}
+
+// record notes the sizes of a rune read for the first time, starting a new
+// segment when they differ from the runes before it.
+func (d *Decoder) record(decodedSize, size int) {
+	if decodedSize == size {
+		decodedSize, size = 1, 1
+	}
+	last := d.segments[len(d.segments)-1]
+	if last.decodedSize != decodedSize || last.size != size {
+		d.segments = append(d.segments, offsetSegment{d.decoded, d.offset, decodedSize, size})
+	}
+}
+
+// readText decodes the text between two offsets in the decoded text again
+// from the source.
+func (d *Decoder) readText(start, end int) (string, bool) {
+	if d.src == nil {
+		return "", false
+	}
+	from, to := d.OriginalOffset(start), d.OriginalOffset(end)
+	section := newDecoder(bufio.NewReader(io.NewSectionReader(d.src, int64(from), int64(to-from))), config{encoding: d.encoding, codePage: d.codePage})
+	section.started, section.segments = true, offsetMap{{decodedSize: 1, size: 1}}
+	text, err := io.ReadAll(section)
+	return string(text), err == nil
+}
+
+// OriginalOffset maps an offset in the decoded text to the offset in the
+// source of the byte that decoded to it.
+func (d *Decoder) OriginalOffset(decoded int) int {
+	return d.segments.OriginalOffset(decoded)
+}
+
+func (m offsetMap) OriginalOffset(decoded int) int {
+	if len(m) == 0 {
+		return decoded
+	}
+	i := sort.Search(len(m), func(i int) bool { return m[i].decoded > decoded }) - 1
+	s := m[max(i, 0)]
+	return s.original + (decoded-s.decoded)/s.decodedSize*s.size
+}
@@ -0,0 +1,174 @@
+package lex
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/denzel-morris/clex/lex/lexemes"
+)
+
+func encodeUTF16(s string, bigEndian bool) []byte {
+	var b []byte
+	for _, unit := range utf16.Encode([]rune(s)) {
+		if bigEndian {
+			b = append(b, byte(unit>>8), byte(unit))
+		} else {
+			b = append(b, byte(unit), byte(unit>>8))
+		}
+	}
+	return b
+}
+
+const decoderSource = "char *s = \"café 😀\";\nint $;\n"
+
+var decoderExpected = []Lexeme{
+	{lexemes.Keyword, "char"},
+	{lexemes.Star, "*"},
+	{lexemes.Identifier, "s"},
+	{lexemes.Equal, "="},
+	{lexemes.StringLiteral, "\"café 😀\""},
+	{lexemes.SemiColon, ";"},
+	{lexemes.Keyword, "int"},
+	{lexemes.Invalid, "$"},
+	{lexemes.SemiColon, ";"},
+}
+
+func TestDecoderSources(t *testing.T) {
+	cases := []struct {
+		name string
+		src  []byte
+		opts []Option
+	}{
+		{"UTF-8 with BOM", append([]byte("\xef\xbb\xbf"), decoderSource...), nil},
+		{"UTF-16LE with BOM", append([]byte{0xFF, 0xFE}, encodeUTF16(decoderSource, false)...), nil},
+		{"UTF-16BE with BOM", append([]byte{0xFE, 0xFF}, encodeUTF16(decoderSource, true)...), nil},
+		{"UTF-16LE", encodeUTF16(decoderSource, false), []Option{WithEncoding(UTF16LE)}},
+		{"UTF-16BE", encodeUTF16(decoderSource, true), []Option{WithEncoding(UTF16BE)}},
+		{"BOM overriding Latin-1", append([]byte{0xFF, 0xFE}, encodeUTF16(decoderSource, false)...), []Option{WithEncoding(Latin1)}},
+	}
+
+	for _, c := range cases {
+		opts := append(c.opts, WithTrivia(false))
+		lexemelist, err := New(bytes.NewReader(c.src), opts...).Lex()
+		if err != nil || !equalLexemes(lexemelist, decoderExpected) {
+			t.Error(c.name, "Expected", decoderExpected, "got", lexemelist, err)
+		}
+
+		lexemelist, err = LexBytes(c.src, opts...)
+		if err != nil || !equalLexemes(lexemelist, decoderExpected) {
+			t.Error(c.name, "LexBytes Expected", decoderExpected, "got", lexemelist, err)
+		}
+	}
+}
+
+func TestDecoderMapsOffsets(t *testing.T) {
+	src := append([]byte{0xFF, 0xFE}, encodeUTF16(decoderSource, false)...)
+	d := NewDecoder(bytes.NewReader(src), UTF8)
+	policy := &CollectingErrorPolicy{}
+	New(d, WithErrorPolicy(policy)).Lex()
+
+	ds := policy.Diagnostics()
+	if len(ds) != 1 {
+		t.Fatal("Expected one diagnostic got", ds)
+	}
+
+	// After the BOM, `$` follows 24 characters taking a code unit each,
+	// except for "😀" which takes two.
+	if offset := d.OriginalOffset(ds[0].Start.Offset); offset != 2+2*25 {
+		t.Error("Expected $ at offset", 2+2*25, "got", offset, "for", ds[0].Start)
+	}
+	if offset := d.OriginalOffset(0); offset != 2 {
+		t.Error("Expected the text to start after the BOM got", offset)
+	}
+}
+
+func TestDecoderMapsOffsetsThroughMultibyteRunes(t *testing.T) {
+	d := NewDecoder(bytes.NewReader(encodeUTF16("a€😀b", true)), UTF16BE)
+	decoded, err := io.ReadAll(d)
+	if err != nil || string(decoded) != "a€😀b" {
+		t.Fatal("Expected a€😀b got", string(decoded), err)
+	}
+
+	// The decoded offsets of a, €, 😀 and b, and where each starts in UTF-16.
+	for decoded, original := range map[int]int{0: 0, 1: 2, 4: 4, 8: 8} {
+		if offset := d.OriginalOffset(decoded); offset != original {
+			t.Error("Expected", original, "for", decoded, "got", offset)
+		}
+	}
+}
+
+func TestDecoderCodePages(t *testing.T) {
+	lexemelist, _ := New(strings.NewReader("s = \"\x93quoted\x94 \x80\xe9\";"), WithEncoding(Windows1252), WithTrivia(false)).Lex()
+	if len(lexemelist) != 4 || lexemelist[2].Value != "\"“quoted” €é\"" {
+		t.Error("Expected Windows-1252 quotes and euro sign got", lexemelist)
+	}
+
+	cp := makeCodePage(map[byte]rune{0x93: 'α', 0x94: 'β'})
+	lexemelist, _ = LexString("s = \x93quoted\x94;", WithCodePage(&cp), WithTrivia(false))
+	if len(lexemelist) != 4 || lexemelist[2] != (Lexeme{lexemes.Identifier, "αquotedβ"}) {
+		t.Error("Expected the custom code page to be used got", lexemelist)
+	}
+}
+
+func TestDecoderUnpairedSurrogates(t *testing.T) {
+	src := []byte{'a', 0, 0x00, 0xD8, 'b', 0, 'c'}
+	d := NewDecoder(bytes.NewReader(src), UTF16LE)
+	decoded, _ := io.ReadAll(d)
+	if string(decoded) != "a�b�" {
+		t.Errorf("Expected %q got %q", "a�b�", decoded)
+	}
+}
+
+func TestUnpairedSurrogatesAreReported(t *testing.T) {
+	// x = "a<D800>"; in UTF-16LE after a byte order mark.
+	src := append([]byte{0xFF, 0xFE}, encodeUTF16("x = \"a", false)...)
+	src = append(src, 0x00, 0xD8, '"', 0, ';', 0)
+
+	lexers := map[string]func(ErrorPolicy) []Lexeme{
+		"New": func(policy ErrorPolicy) []Lexeme {
+			lexemelist, _ := New(bytes.NewReader(src), WithErrorPolicy(policy), WithTrivia(false)).Lex()
+			return lexemelist
+		},
+		"LexBytes": func(policy ErrorPolicy) []Lexeme {
+			lexemelist, _ := LexBytes(src, WithErrorPolicy(policy), WithTrivia(false))
+			return lexemelist
+		},
+	}
+	for name, lex := range lexers {
+		policy := &CollectingErrorPolicy{}
+		lexemelist := lex(policy)
+		if len(lexemelist) != 4 || lexemelist[2] != (Lexeme{lexemes.StringLiteral, "\"a\xef\xbf\xbd\""}) {
+			t.Error(name, "Expected the surrogate to be replaced in the string literal, got", lexemelist)
+		}
+
+		ds := policy.Diagnostics()
+		expected := "Unpaired UTF-16 surrogate 0xD800 at offset 14"
+		if len(ds) != 1 || ds[0].Code != CodeUnpairedSurrogate || ds[0].Message != expected || ds[0].Position.Column != 7 {
+			t.Error(name, "Expected", expected, "at column 7 got", ds)
+		}
+	}
+}
+
+func TestOriginalOffsetsOfLexersAndFiles(t *testing.T) {
+	src := "\xef\xbb\xbfint $;"
+	policy := &CollectingErrorPolicy{}
+	lexer := New(strings.NewReader(src), WithErrorPolicy(policy))
+	lexer.Lex()
+	if offset := OriginalOffset(lexer, policy.Diagnostics()[0].Start.Offset); offset != 7 {
+		t.Error("Expected $ at offset 7 of the source got", offset)
+	}
+	if offset := OriginalOffset(New(strings.NewReader("int $;")), 4); offset != 4 {
+		t.Error("Expected offsets of UTF-8 without a byte order mark to be kept, got", offset)
+	}
+
+	root := writeSourceTree(t, map[string]string{"bom.c": src})
+	results, _ := LexFiles(context.Background(), []string{filepath.Join(root, "bom.c")}, 1)
+	if offset := results[0].OriginalOffset(results[0].Diagnostics[0].Start.Offset); offset != 7 {
+		t.Error("Expected $ at offset 7 of the file got", offset)
+	}
+}
@@ -0,0 +1,120 @@
+package diag
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/denzel-morris/clex/lex"
+)
+
+const DefaultTabWidth = 8
+
+const (
+	ansiReset   = "\x1b[0m"
+	ansiBold    = "\x1b[1m"
+	ansiRed     = "\x1b[1;31m"
+	ansiGreen   = "\x1b[1;32m"
+	ansiMagenta = "\x1b[1;35m"
+	ansiCyan    = "\x1b[1;36m"
+)
+
+var severityToColor = map[lex.Severity]string{
+	lex.SeverityError:   ansiRed,
+	lex.SeverityWarning: ansiMagenta,
+	lex.SeverityNote:    ansiCyan,
+}
+
+// Renderer prints diagnostics in the style of clang:
+//
+//	test.c:20:32: error: Expected 4 hexadecimal characters for universal character name
+//	    const char* s = "Hello \u042";
+//	                    ~~~~~~~~~~~~^
+//
+// TabStops reports that the columns of diagnostics already account for tab
+// stops every TabWidth columns, as with a lexer built WithTabWidth, rather
+// than counting each rune as one column.
+type Renderer struct {
+	TabWidth int
+	TabStops bool
+	Color    bool
+
+	w        io.Writer
+	filename string
+	source   Source
+}
+
+// NewRenderer returns a renderer writing to w. The source is consulted for
+// the complete line of each diagnostic; when it is nil, or does not have the
+// line, the partial line recorded in the diagnostic is shown instead. When
+// filename is empty the file recorded in the diagnostic is used.
+func NewRenderer(w io.Writer, filename string, source Source) *Renderer {
+	return &Renderer{
+		TabWidth: DefaultTabWidth,
+		w:        w,
+		filename: filename,
+		source:   source,
+	}
+}
+
+func (r *Renderer) Render(d lex.Diagnostic) error {
+	line := r.line(d)
+	_, err := fmt.Fprintf(r.w, "%s %s %s\n%s\n%s\n",
+		r.paint(ansiBold, r.location(d)+":"),
+		r.paint(severityToColor[d.Severity], d.Severity.String()+":"),
+		r.paint(ansiBold, d.Message),
+		expandTabs(line, r.tabWidth()),
+		r.paint(ansiGreen, r.underline(line, d)),
+	)
+	return err
+}
+
+func (r *Renderer) location(d lex.Diagnostic) string {
+	filename := r.filename
+	if filename == "" {
+		filename = d.File
+	}
+	if filename == "" {
+		return d.Position.String()
+	}
+	return filename + ":" + d.Position.String()
+}
+
+func (r *Renderer) line(d lex.Diagnostic) string {
+	if r.source != nil {
+		if line, ok := r.source.SourceLine(d.Position.Line); ok {
+			return line
+		}
+	}
+	return strings.TrimSuffix(d.Line, "\r")
+}
+
+func (r *Renderer) underline(line string, d lex.Diagnostic) string {
+	caret := r.visualColumn(line, d.Position.Column)
+	start := caret
+	if d.Start.Line == d.Position.Line && d.Start.Column < d.Position.Column {
+		start = r.visualColumn(line, d.Start.Column)
+	}
+	return strings.Repeat(" ", start) + strings.Repeat("~", caret-start) + "^"
+}
+
+func (r *Renderer) visualColumn(line string, column int) int {
+	if r.TabStops {
+		return column - 1
+	}
+	return visualColumn(line, column, r.tabWidth())
+}
+
+func (r *Renderer) paint(color, s string) string {
+	if !r.Color || color == "" {
+		return s
+	}
+	return color + s + ansiReset
+}
+
+func (r *Renderer) tabWidth() int {
+	if r.TabWidth <= 0 {
+		return DefaultTabWidth
+	}
+	return r.TabWidth
+}
@@ -0,0 +1,92 @@
+package diag
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/denzel-morris/clex/lex"
+)
+
+func TestRendererUnderlinesSpan(t *testing.T) {
+	var out bytes.Buffer
+	src := []byte("int x = 0x;\n")
+	r := NewRenderer(&out, "test.c", SplitLines(src))
+
+	r.Render(lex.Diagnostic{
+		Message:  "Hexadecimal constant must contain at least one digit",
+		Line:     "int x = 0x",
+		Start:    lex.Position{Line: 1, Column: 9},
+		Position: lex.Position{Line: 1, Column: 11},
+	})
+
+	expected := "test.c:1:11: error: Hexadecimal constant must contain at least one digit\n" +
+		"int x = 0x;\n" +
+		"        ~~^\n"
+	if out.String() != expected {
+		t.Errorf("Expected\n%s\ngot\n%s", expected, out.String())
+	}
+}
+
+func TestRendererAlignsCaretAfterTabs(t *testing.T) {
+	var out bytes.Buffer
+	src := []byte("\ta\t$;")
+	r := NewRenderer(&out, "", SplitLines(src))
+	r.TabWidth = 4
+
+	r.Render(lex.Diagnostic{
+		Severity: lex.SeverityWarning,
+		Message:  "m",
+		Start:    lex.Position{Line: 1, Column: 4},
+		Position: lex.Position{Line: 1, Column: 4},
+	})
+
+	expected := "1:4: warning: m\n" +
+		"    a   $;\n" +
+		"        ^\n"
+	if out.String() != expected {
+		t.Errorf("Expected\n%s\ngot\n%s", expected, out.String())
+	}
+}
+
+func TestRendererFallsBackToPartialLine(t *testing.T) {
+	var out bytes.Buffer
+	r := NewRenderer(&out, "test.c", nil)
+	r.Color = true
+
+	r.Render(lex.Diagnostic{
+		Message:  "m",
+		Line:     "ab",
+		Start:    lex.Position{Line: 3, Column: 3},
+		Position: lex.Position{Line: 3, Column: 3},
+	})
+
+	expected := ansiBold + "test.c:3:3:" + ansiReset + " " +
+		ansiRed + "error:" + ansiReset + " " +
+		ansiBold + "m" + ansiReset + "\n" +
+		"ab\n" +
+		ansiGreen + "  ^" + ansiReset + "\n"
+	if out.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, out.String())
+	}
+}
+
+func TestRendererUsesTabStopColumns(t *testing.T) {
+	var out bytes.Buffer
+	r := NewRenderer(&out, "", SplitLines([]byte("\ta\t$;")))
+	r.TabWidth = 4
+	r.TabStops = true
+
+	r.Render(lex.Diagnostic{
+		Message:  "m",
+		File:     "test.c",
+		Start:    lex.Position{Line: 1, Column: 9},
+		Position: lex.Position{Line: 1, Column: 9},
+	})
+
+	expected := "test.c:1:9: error: m\n" +
+		"    a   $;\n" +
+		"        ^\n"
+	if out.String() != expected {
+		t.Errorf("Expected\n%s\ngot\n%s", expected, out.String())
+	}
+}
@@ -0,0 +1,74 @@
+package diag
+
+import (
+	"bytes"
+	"os"
+	"strings"
+)
+
+// Source gives the renderer access to complete physical lines of the file
+// being diagnosed. Lines are numbered from 1.
+type Source interface {
+	SourceLine(line int) (string, bool)
+}
+
+type Lines []string
+
+func SplitLines(src []byte) Lines {
+	return Lines(strings.Split(string(src), "\n"))
+}
+
+func (ls Lines) SourceLine(line int) (string, bool) {
+	if line < 1 || line > len(ls) {
+		return "", false
+	}
+	return strings.TrimSuffix(ls[line-1], "\r"), true
+}
+
+// IsTerminal reports whether f refers to a character device, which is how
+// the renderer decides to emit ANSI colors by default.
+func IsTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return fi.Mode()&os.ModeCharDevice != 0
+}
+
+func expandTabs(line string, tabWidth int) string {
+	if !strings.ContainsRune(line, '\t') {
+		return line
+	}
+
+	var buf bytes.Buffer
+	column := 0
+	for _, r := range line {
+		if r == '\t' {
+			n := tabWidth - column%tabWidth
+			buf.WriteString(strings.Repeat(" ", n))
+			column += n
+			continue
+		}
+		buf.WriteRune(r)
+		column++
+	}
+	return buf.String()
+}
+
+// visualColumn converts a 1-based rune column into a 0-based display
+// column, expanding the tabs that precede it.
+func visualColumn(line string, column, tabWidth int) int {
+	visual, i := 0, 1
+	for _, r := range line {
+		if i >= column {
+			break
+		}
+		if r == '\t' {
+			visual += tabWidth - visual%tabWidth
+		} else {
+			visual++
+		}
+		i++
+	}
+	return visual + column - i
+}
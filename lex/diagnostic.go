@@ -0,0 +1,50 @@
+package lex
+
+type Severity int
+
+const (
+	SeverityError Severity = iota
+	SeverityWarning
+	SeverityNote
+)
+
+var severityToName = map[Severity]string{
+	SeverityError:   "error",
+	SeverityWarning: "warning",
+	SeverityNote:    "note",
+}
+
+func (s Severity) String() string {
+	return severityToName[s]
+}
+
+// Diagnostic describes a problem found while lexing. Position is where the
+// problem was detected and Start is where the offending lexeme begins, so
+// that [Start, Position) is the span of input the lexer had consumed.
+type Diagnostic struct {
+	Severity Severity
+	Code     Code
+	Message  string
+	File     string
+	Line     string
+	Start    Position
+	Position Position
+	Fixes    []Fix
+}
+
+// Fix is a suggested edit that replaces the input between Start and End
+// (exclusive) with Text. When Start equals End the fix is an insertion.
+type Fix struct {
+	Description string
+	Start, End  Position
+	Text        string
+}
+
+// DiagnosticPolicy is an ErrorPolicy that wants the full Diagnostic rather
+// than its message, line and position. The lexer prefers ReportDiagnostic
+// whenever the policy implements it. Warnings and notes are only reported to
+// a DiagnosticPolicy, since ReportError has no way to tell them from errors.
+type DiagnosticPolicy interface {
+	ErrorPolicy
+	ReportDiagnostic(d Diagnostic)
+}
@@ -0,0 +1,161 @@
+package lex
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// Encoding is the character encoding of a source.
+type Encoding int
+
+const (
+	UTF8 Encoding = iota
+	Latin1
+	Windows1252
+	UTF16LE
+	UTF16BE
+)
+
+var encodingToName = map[Encoding]string{
+	UTF8:        "UTF-8",
+	Latin1:      "ISO-8859-1",
+	Windows1252: "Windows-1252",
+	UTF16LE:     "UTF-16LE",
+	UTF16BE:     "UTF-16BE",
+}
+
+func (e Encoding) String() string {
+	return encodingToName[e]
+}
+
+// CodePage maps the bytes 0x80 to 0xFF of a single-byte encoding to runes.
+// Bytes below 0x80 are ASCII in every code page.
+type CodePage [128]rune
+
+var latin1CodePage = makeCodePage(nil)
+
+var windows1252CodePage = makeCodePage(map[byte]rune{
+	0x80: '€', 0x82: '‚', 0x83: 'ƒ', 0x84: '„', 0x85: '…', 0x86: '†', 0x87: '‡',
+	0x88: 'ˆ', 0x89: '‰', 0x8A: 'Š', 0x8B: '‹', 0x8C: 'Œ', 0x8E: 'Ž',
+	0x91: '‘', 0x92: '’', 0x93: '“', 0x94: '”', 0x95: '•', 0x96: '–', 0x97: '—',
+	0x98: '˜', 0x99: '™', 0x9A: 'š', 0x9B: '›', 0x9C: 'œ', 0x9E: 'ž', 0x9F: 'Ÿ',
+})
+
+var encodingToCodePage = map[Encoding]*CodePage{
+	Latin1:      &latin1CodePage,
+	Windows1252: &windows1252CodePage,
+}
+
+// makeCodePage returns the code page mapping every byte to the rune of the
+// same value, as ISO-8859-1 does, except for those in overrides.
+func makeCodePage(overrides map[byte]rune) CodePage {
+	var cp CodePage
+	for i := range cp {
+		cp[i] = rune(0x80 + i)
+	}
+	for b, r := range overrides {
+		cp[b-0x80] = r
+	}
+	return cp
+}
+
+// Bytes that aren't valid UTF-8 are read as the runes U+DC80 to U+DCFF, the
+// surrogate escapes of PEP 383. Decoding UTF-8 never yields a surrogate, so
+// an escaped byte can't be mistaken for a rune of the source.
+const escapedByteBase = 0xDC00
+
+func escapeByte(b byte) rune {
+	return escapedByteBase + rune(b)
+}
+
+func unescapeByte(r rune) (byte, bool) {
+	if r < escapedByteBase+0x80 || r > escapedByteBase+0xFF {
+		return 0, false
+	}
+	return byte(r - escapedByteBase), true
+}
+
+func isEscapedByte(r rune) bool {
+	_, ok := unescapeByte(r)
+	return ok
+}
+
+// Unpaired UTF-16 surrogates are read as runes past the last Unicode
+// character, so that they are told apart from a U+FFFD in the source. They
+// take the place of U+FFFD in decoded text.
+const escapedSurrogateBase = unicode.MaxRune + 1
+
+func escapeSurrogate(unit rune) rune {
+	return escapedSurrogateBase + unit
+}
+
+func unescapeSurrogate(r rune) (rune, bool) {
+	if r < escapedSurrogateBase+0xD800 || r > escapedSurrogateBase+0xDFFF {
+		return 0, false
+	}
+	return r - escapedSurrogateBase, true
+}
+
+// isUndecodable reports whether r stands for source bytes that don't decode
+// to a character.
+func isUndecodable(r rune) bool {
+	_, ok := unescapeSurrogate(r)
+	return ok || isEscapedByte(r)
+}
+
+// newScanner returns the scanner the reader chain built by New reads from.
+// Plain UTF-8 is read directly, while a source with a byte order mark or in
+// another encoding goes through a Decoder.
+func newScanner(r io.Reader, cfg config) io.RuneScanner {
+	if d, ok := r.(*Decoder); ok {
+		return d
+	}
+
+	br, ok := r.(*bufio.Reader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+	if cfg.encoding == UTF8 && cfg.codePage == nil && peekBOM(br) < 0 {
+		return br
+	}
+	d := newDecoder(br, cfg)
+	d.src, _ = r.(io.ReaderAt)
+	return d
+}
+
+// decode converts src to UTF-8 for LexString and LexBytes, returning it as
+// it is when it already is UTF-8. Along with the text it returns the map of
+// its offsets to those of src, and the unpaired surrogates it replaced with
+// U+FFFD by their offset in the text.
+func decode(src string, cfg config) (string, offsetMap, map[int]rune) {
+	if cfg.encoding == UTF8 && cfg.codePage == nil {
+		switch i := detectBOM(src); {
+		case i < 0:
+			return src, nil, nil
+		case byteOrderMarks[i].encoding == UTF8:
+			bom := len(byteOrderMarks[i].bom)
+			return src[bom:], offsetMap{{original: bom, decodedSize: 1, size: 1}}, nil
+		}
+	}
+
+	d := newDecoder(bufio.NewReader(strings.NewReader(src)), cfg)
+	var sb strings.Builder
+	var surrogates map[int]rune
+	sb.Grow(len(src))
+	for r, _, err := d.ReadRune(); err == nil; r, _, err = d.ReadRune() {
+		if b, ok := unescapeByte(r); ok {
+			sb.WriteByte(b)
+			continue
+		}
+		if unit, ok := unescapeSurrogate(r); ok {
+			if surrogates == nil {
+				surrogates = map[int]rune{}
+			}
+			surrogates[sb.Len()] = unit
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String(), d.segments, surrogates
+}
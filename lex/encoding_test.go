@@ -0,0 +1,120 @@
+package lex
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/denzel-morris/clex/lex/lexemes"
+)
+
+const invalidUTF8Input = "int a\xff;\n\"x\xe2\x82y\" // \xc3"
+
+var invalidUTF8Lexers = map[string]func(policy ErrorPolicy, opts ...Option) ([]Lexeme, error){
+	"New": func(policy ErrorPolicy, opts ...Option) ([]Lexeme, error) {
+		return New(strings.NewReader(invalidUTF8Input), append(opts, WithErrorPolicy(policy))...).Lex()
+	},
+	"lineReader": func(policy ErrorPolicy, opts ...Option) ([]Lexeme, error) {
+		rd := NewLineReader(NewLookaheadReader(strings.NewReader(invalidUTF8Input), 4))
+		return NewLexer(rd, policy, opts...).Lex()
+	},
+	"LexString": func(policy ErrorPolicy, opts ...Option) ([]Lexeme, error) {
+		return LexString(invalidUTF8Input, append(opts, WithErrorPolicy(policy))...)
+	},
+}
+
+func TestInvalidUTF8IsReported(t *testing.T) {
+	expected := []struct {
+		message      string
+		line, column int
+	}{
+		{"Invalid UTF-8 byte 0xFF at offset 5", 1, 6},
+		{"Invalid UTF-8 byte 0xE2 at offset 10", 2, 3},
+		{"Invalid UTF-8 byte 0x82 at offset 11", 2, 4},
+		{"Invalid UTF-8 byte 0xC3 at offset 18", 2, 11},
+	}
+
+	for name, lex := range invalidUTF8Lexers {
+		policy := &CollectingErrorPolicy{}
+		lex(policy)
+
+		ds := policy.Diagnostics()
+		if len(ds) != len(expected) {
+			t.Fatal(name, "Expected", len(expected), "diagnostics got", ds)
+		}
+		for i, d := range ds {
+			e := expected[i]
+			if d.Code != CodeInvalidUTF8 || d.Message != e.message || d.Position.Line != e.line || d.Position.Column != e.column {
+				t.Error(name, "Expected", e, "got", d.Message, d.Position)
+			}
+		}
+	}
+}
+
+func TestInvalidUTF8Lexemes(t *testing.T) {
+	replaced := []Lexeme{
+		{lexemes.Keyword, "int"},
+		{lexemes.Whitespace, " "},
+		{lexemes.Identifier, "a"},
+		{lexemes.Invalid, "�"},
+		{lexemes.SemiColon, ";"},
+		{lexemes.Whitespace, "\n"},
+		{lexemes.StringLiteral, "\"x��y\""},
+		{lexemes.Whitespace, " "},
+		{lexemes.Comment, "// �"},
+	}
+	raw := []Lexeme{
+		{lexemes.Keyword, "int"},
+		{lexemes.Whitespace, " "},
+		{lexemes.Identifier, "a"},
+		{lexemes.Invalid, "\xff"},
+		{lexemes.SemiColon, ";"},
+		{lexemes.Whitespace, "\n"},
+		{lexemes.StringLiteral, "\"x\xe2\x82y\""},
+		{lexemes.Whitespace, " "},
+		{lexemes.Comment, "// \xc3"},
+	}
+
+	for name, lex := range invalidUTF8Lexers {
+		if lexemelist, _ := lex(discardErrorPolicy{}); !equalLexemes(lexemelist, replaced) {
+			t.Errorf("%s: expected %q got %q", name, replaced, lexemelist)
+		}
+		if lexemelist, _ := lex(discardErrorPolicy{}, WithRawBytes(true)); !equalLexemes(lexemelist, raw) {
+			t.Errorf("%s: expected %q got %q", name, raw, lexemelist)
+		}
+	}
+}
+
+func TestLatin1(t *testing.T) {
+	src := "char *caf\xe9 = \"\xa9 1999\";"
+	expected := []Lexeme{
+		{lexemes.Keyword, "char"},
+		{lexemes.Star, "*"},
+		{lexemes.Identifier, "café"},
+		{lexemes.Equal, "="},
+		{lexemes.StringLiteral, "\"© 1999\""},
+		{lexemes.SemiColon, ";"},
+	}
+
+	policy := &CollectingErrorPolicy{}
+	lexemelist, _ := New(strings.NewReader(src), WithEncoding(Latin1), WithTrivia(false), WithErrorPolicy(policy)).Lex()
+	if !equalLexemes(lexemelist, expected) || len(policy.Diagnostics()) != 0 {
+		t.Error("Expected", expected, "got", lexemelist, policy.Diagnostics())
+	}
+
+	lexemelist, _ = LexString(src, WithEncoding(Latin1), WithTrivia(false), WithErrorPolicy(policy))
+	if !equalLexemes(lexemelist, expected) || len(policy.Diagnostics()) != 0 {
+		t.Error("Expected", expected, "got", lexemelist, policy.Diagnostics())
+	}
+}
+
+func TestLatin1IdentifierStart(t *testing.T) {
+	src := "\xb7x"
+	for _, raw := range []bool{false, true} {
+		policy := &CollectingErrorPolicy{}
+		LexString(src, WithEncoding(Latin1), WithStandard(C23), WithRawBytes(raw), WithErrorPolicy(policy))
+		ds := policy.Diagnostics()
+		if len(ds) != 1 || ds[0].Code != CodeInvalidIdentifierStart {
+			t.Error("Expected", CodeInvalidIdentifierStart, "for", src, "with raw bytes", raw, "got", ds)
+		}
+	}
+}
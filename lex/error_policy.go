@@ -1,5 +1,141 @@
 package lex
 
+import (
+	"fmt"
+	"sort"
+)
+
 type ErrorPolicy interface {
 	ReportError(message string, line string, position Position)
 }
+
+// AbortingErrorPolicy is an ErrorPolicy that can stop the lexer. The lexer
+// calls Abort after every report, and once it returns an error the lexer
+// returns that error from Next instead of lexing any further.
+type AbortingErrorPolicy interface {
+	ErrorPolicy
+	Abort() error
+}
+
+// Error is the error returned by a lexer stopped by FailFastErrorPolicy.
+type Error struct {
+	Diagnostic
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %s", e.Position, e.Message)
+}
+
+type CollectingErrorPolicy struct {
+	diagnostics []Diagnostic
+}
+
+func (ep *CollectingErrorPolicy) ReportError(message string, line string, position Position) {
+	ep.ReportDiagnostic(makeDiagnostic(message, line, position))
+}
+
+func (ep *CollectingErrorPolicy) ReportDiagnostic(d Diagnostic) {
+	ep.diagnostics = append(ep.diagnostics, d)
+}
+
+// Diagnostics returns the reported diagnostics ordered by position.
+func (ep *CollectingErrorPolicy) Diagnostics() []Diagnostic {
+	diagnostics := append([]Diagnostic(nil), ep.diagnostics...)
+	sort.SliceStable(diagnostics, func(i, j int) bool {
+		pi, pj := diagnostics[i].Position, diagnostics[j].Position
+		return pi.Line < pj.Line || pi.Line == pj.Line && pi.Column < pj.Column
+	})
+	return diagnostics
+}
+
+type CountingErrorPolicy struct {
+	count int
+}
+
+func (ep *CountingErrorPolicy) ReportError(message string, line string, position Position) {
+	ep.count++
+}
+
+func (ep *CountingErrorPolicy) Count() int {
+	return ep.count
+}
+
+// FailFastErrorPolicy stops the lexer at the first error, which the lexer
+// then returns as an *Error. Warnings and notes are ignored.
+type FailFastErrorPolicy struct {
+	err *Error
+}
+
+func (ep *FailFastErrorPolicy) ReportError(message string, line string, position Position) {
+	ep.ReportDiagnostic(makeDiagnostic(message, line, position))
+}
+
+func (ep *FailFastErrorPolicy) ReportDiagnostic(d Diagnostic) {
+	if ep.err == nil && d.Severity == SeverityError {
+		ep.err = &Error{d}
+	}
+}
+
+func (ep *FailFastErrorPolicy) Abort() error {
+	if ep.err == nil {
+		return nil
+	}
+	return ep.err
+}
+
+type teeErrorPolicy struct {
+	policies []ErrorPolicy
+}
+
+// NewTeeErrorPolicy returns a policy that reports to each of policies in
+// turn. It aborts as soon as any of them does.
+func NewTeeErrorPolicy(policies ...ErrorPolicy) AbortingErrorPolicy {
+	return &teeErrorPolicy{policies}
+}
+
+func (ep *teeErrorPolicy) ReportError(message string, line string, position Position) {
+	ep.ReportDiagnostic(makeDiagnostic(message, line, position))
+}
+
+func (ep *teeErrorPolicy) ReportDiagnostic(d Diagnostic) {
+	for _, policy := range ep.policies {
+		reportTo(policy, d)
+	}
+}
+
+func (ep *teeErrorPolicy) Abort() error {
+	for _, policy := range ep.policies {
+		if err := abortFrom(policy); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func makeDiagnostic(message string, line string, position Position) Diagnostic {
+	return Diagnostic{
+		Severity: SeverityError,
+		Message:  message,
+		Line:     line,
+		Start:    position,
+		Position: position,
+	}
+}
+
+func reportTo(policy ErrorPolicy, d Diagnostic) {
+	switch policy := policy.(type) {
+	case DiagnosticPolicy:
+		policy.ReportDiagnostic(d)
+	default:
+		if d.Severity == SeverityError {
+			policy.ReportError(d.Message, d.Line, d.Position)
+		}
+	}
+}
+
+func abortFrom(policy ErrorPolicy) error {
+	if policy, ok := policy.(AbortingErrorPolicy); ok {
+		return policy.Abort()
+	}
+	return nil
+}
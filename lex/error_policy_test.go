@@ -0,0 +1,76 @@
+package lex
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/denzel-morris/clex/lex/lexemes"
+)
+
+func TestCollectingErrorPolicySortsByPosition(t *testing.T) {
+	policy := &CollectingErrorPolicy{}
+	policy.ReportError("c", "", Position{Line: 2, Column: 1})
+	policy.ReportError("b", "", Position{Line: 1, Column: 7})
+	policy.ReportDiagnostic(Diagnostic{Message: "a", Position: Position{Line: 1, Column: 3}})
+
+	var messages string
+	for _, d := range policy.Diagnostics() {
+		messages += d.Message
+	}
+	if messages != "abc" {
+		t.Error("Expected diagnostics in order abc, got", messages)
+	}
+}
+
+func TestFailFastErrorPolicyStopsLexer(t *testing.T) {
+	policy := &FailFastErrorPolicy{}
+	lexemelist, err := makeLookaheadLexer("a $ b $", policy).Lex()
+
+	var lexErr *Error
+	if !errors.As(err, &lexErr) {
+		t.Fatal("Expected *Error, got", err)
+	}
+	if lexErr.Code != CodeUnrecognizedCharacter || lexErr.Start.Column != 3 {
+		t.Error("Expected unrecognized character at column 3, got", lexErr)
+	}
+
+	expected := []Lexeme{
+		{lexemes.Identifier, "a"},
+		{lexemes.Whitespace, " "},
+		{lexemes.Invalid, "$"},
+	}
+	if !equalLexemes(lexemelist, expected) {
+		t.Error("Expected", expected, "got", lexemelist)
+	}
+}
+
+func TestTeeErrorPolicyReportsToEveryPolicy(t *testing.T) {
+	counting, collecting := &CountingErrorPolicy{}, &CollectingErrorPolicy{}
+	policy := NewTeeErrorPolicy(counting, collecting, &FailFastErrorPolicy{})
+
+	_, err := makeLookaheadLexer("$ $", policy).Lex()
+	if err == nil {
+		t.Error("Expected the tee to abort when the fail-fast policy does")
+	}
+
+	if counting.Count() != 1 {
+		t.Error("Expected 1 error counted, got", counting.Count())
+	}
+	if diagnostics := collecting.Diagnostics(); len(diagnostics) != 1 || diagnostics[0].Code != CodeUnrecognizedCharacter {
+		t.Error("Expected 1 unrecognized character diagnostic, got", diagnostics)
+	}
+}
+
+func TestErrorPoliciesOnlyReceiveErrors(t *testing.T) {
+	src := "/* \u202E */ \"\u2066\" $"
+	counting, collecting := &CountingErrorPolicy{}, &CollectingErrorPolicy{}
+	New(strings.NewReader(src), WithErrorPolicy(NewTeeErrorPolicy(counting, collecting))).Lex()
+
+	if counting.Count() != 1 {
+		t.Error("Expected only the error to be counted, got", counting.Count())
+	}
+	if diagnostics := collecting.Diagnostics(); len(diagnostics) != 3 {
+		t.Error("Expected 2 warnings and 1 error, got", diagnostics)
+	}
+}
@@ -0,0 +1,189 @@
+package lex
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// FileResult is the outcome of lexing one file with LexFiles.
+type FileResult struct {
+	Path        string
+	Source      []byte
+	Lexemes     []Lexeme
+	Positions   []Position // where each of Lexemes starts
+	Diagnostics []Diagnostic
+	Err         error
+	Duration    time.Duration
+
+	offsets offsetMapper
+}
+
+// OriginalOffset maps the offset of a position in the result, which counts
+// bytes of the decoded UTF-8 text, to the offset in Source of the byte it was
+// decoded from.
+func (r FileResult) OriginalOffset(offset int) int {
+	if r.offsets == nil {
+		return offset
+	}
+	return r.offsets.OriginalOffset(offset)
+}
+
+// Summary aggregates the results of LexFiles.
+type Summary struct {
+	Files   int
+	Failed  int // files whose Err is set
+	Bytes   int64
+	Lexemes int
+	Errors  int // diagnostics with SeverityError
+	Elapsed time.Duration
+	Total   time.Duration // sum of every file's Duration
+}
+
+// LexFiles lexes each of paths with its own lexer, running at most workers
+// lexers at once, or GOMAXPROCS when workers isn't positive. Results are in
+// the order of paths whatever order the files finish in.
+//
+// Each file's diagnostics are collected into its result, overriding any
+// WithErrorPolicy in opts, and the file name is set to its path. Once ctx is
+// done, files not yet lexed fail with ctx.Err().
+func LexFiles(ctx context.Context, paths []string, workers int, opts ...Option) ([]FileResult, Summary) {
+	results := make([]FileResult, 0, len(paths))
+	summary := LexFilesFunc(ctx, paths, workers, func(result FileResult) {
+		results = append(results, result)
+	}, opts...)
+	return results, summary
+}
+
+// LexFilesFunc is like LexFiles, except that each result is passed to fn,
+// in the order of paths, rather than kept. Only the results of a few files
+// per worker are held at once, so memory use doesn't grow with the number of
+// files. fn is called from a single goroutine.
+func LexFilesFunc(ctx context.Context, paths []string, workers int, fn func(FileResult), opts ...Option) Summary {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	start := time.Now()
+	type indexedResult struct {
+		index  int
+		result FileResult
+	}
+
+	// Files are only handed out while fewer than window results are
+	// waiting for fn, so that a slow file doesn't let the others pile up.
+	window := make(chan struct{}, 2*workers)
+	indices := make(chan int)
+	go func() {
+		defer close(indices)
+		for i := range paths {
+			window <- struct{}{}
+			indices <- i
+		}
+	}()
+
+	finished := make(chan indexedResult)
+	var wg sync.WaitGroup
+	for range min(workers, len(paths)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				finished <- indexedResult{i, lexFile(ctx, paths[i], opts)}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(finished)
+	}()
+
+	summary := Summary{Files: len(paths)}
+	pending := map[int]FileResult{}
+	next := 0
+	for r := range finished {
+		pending[r.index] = r.result
+		for result, ok := pending[next]; ok; result, ok = pending[next] {
+			delete(pending, next)
+			summary.add(result)
+			fn(result)
+			<-window
+			next++
+		}
+	}
+	summary.Elapsed = time.Since(start)
+	return summary
+}
+
+func (s *Summary) add(result FileResult) {
+	if result.Err != nil {
+		s.Failed++
+	}
+	s.Bytes += int64(len(result.Source))
+	s.Lexemes += len(result.Lexemes)
+	s.Total += result.Duration
+	for _, d := range result.Diagnostics {
+		if d.Severity == SeverityError {
+			s.Errors++
+		}
+	}
+}
+
+func lexFile(ctx context.Context, path string, opts []Option) (result FileResult) {
+	result.Path = path
+	if result.Err = ctx.Err(); result.Err != nil {
+		return result
+	}
+
+	start := time.Now()
+	defer func() { result.Duration = time.Since(start) }()
+
+	if result.Source, result.Err = os.ReadFile(path); result.Err != nil {
+		return result
+	}
+
+	policy := &CollectingErrorPolicy{}
+	cfg := makeConfig(append(opts[:len(opts):len(opts)], WithErrorPolicy(policy), WithFileName(path)))
+	l := newStringLexer(string(result.Source), cfg)
+	result.Lexemes, result.Err = lexContext(ctx, l, func() {
+		result.Positions = append(result.Positions, l.start)
+	})
+	result.offsets = l.offsets
+	result.Diagnostics = policy.Diagnostics()
+	return result
+}
+
+// SourceFiles expands paths into the C source and header files they name.
+// Directories are walked recursively, collecting files ending in .c or .h
+// in lexical order; any other path is kept as it is.
+func SourceFiles(paths ...string) ([]string, error) {
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+
+		err = filepath.WalkDir(path, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if ext := filepath.Ext(path); !d.IsDir() && (ext == ".c" || ext == ".h") {
+				files = append(files, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
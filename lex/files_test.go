@@ -0,0 +1,167 @@
+package lex
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/denzel-morris/clex/lex/lexemes"
+)
+
+func writeSourceTree(t *testing.T, files map[string]string) string {
+	root := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func TestSourceFilesWalksDirectories(t *testing.T) {
+	root := writeSourceTree(t, map[string]string{
+		"b.c":          "",
+		"a.h":          "",
+		"notes.txt":    "",
+		"sub/z.c":      "",
+		"sub/deep/y.h": "",
+	})
+
+	files, err := SourceFiles(root, filepath.Join(root, "notes.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"a.h", "b.c", "sub/deep/y.h", "sub/z.c", "notes.txt"}
+	if len(files) != len(expected) {
+		t.Fatal("Expected", expected, "got", files)
+	}
+	for i, name := range expected {
+		if files[i] != filepath.Join(root, name) {
+			t.Error("Expected", filepath.Join(root, name), "got", files[i])
+		}
+	}
+}
+
+func TestLexFilesKeepsOrder(t *testing.T) {
+	files := map[string]string{}
+	var paths []string
+	for i := 0; i < 50; i++ {
+		name := "f" + strconv.Itoa(i) + ".c"
+		files[name] = "int x" + strconv.Itoa(i) + ";\n"
+		paths = append(paths, name)
+	}
+	root := writeSourceTree(t, files)
+	for i := range paths {
+		paths[i] = filepath.Join(root, paths[i])
+	}
+	paths = append(paths, filepath.Join(root, "missing.c"))
+
+	results, summary := LexFiles(context.Background(), paths, 4, WithTrivia(false))
+	for i, result := range results[:50] {
+		expected := []Lexeme{
+			{lexemes.Keyword, "int"},
+			{lexemes.Identifier, "x" + strconv.Itoa(i)},
+			{lexemes.SemiColon, ";"},
+		}
+		if result.Path != paths[i] || result.Err != nil || !equalLexemes(result.Lexemes, expected) {
+			t.Error("Expected", expected, "for", paths[i], "got", result.Path, result.Lexemes, result.Err)
+		}
+	}
+	if !os.IsNotExist(results[50].Err) {
+		t.Error("Expected missing file to fail got", results[50].Err)
+	}
+
+	if summary.Files != 51 || summary.Failed != 1 || summary.Lexemes != 150 {
+		t.Error("Expected 51 files, 1 failed and 150 lexemes got", summary)
+	}
+	if summary.Total <= 0 || summary.Elapsed <= 0 {
+		t.Error("Expected timings to be recorded got", summary)
+	}
+}
+
+func TestLexFilesCollectsDiagnosticsPerFile(t *testing.T) {
+	root := writeSourceTree(t, map[string]string{
+		"bad.c":  "int $;\n\"open\n",
+		"good.c": "int x;\n",
+	})
+	paths := []string{filepath.Join(root, "bad.c"), filepath.Join(root, "good.c")}
+
+	results, summary := LexFiles(context.Background(), paths, 0, WithErrorPolicy(&FailFastErrorPolicy{}))
+	if len(results[0].Diagnostics) != 2 || len(results[1].Diagnostics) != 0 {
+		t.Fatal("Expected 2 and 0 diagnostics got", results[0].Diagnostics, results[1].Diagnostics)
+	}
+	for _, d := range results[0].Diagnostics {
+		if d.File != paths[0] {
+			t.Error("Expected diagnostic in", paths[0], "got", d.File)
+		}
+	}
+	if summary.Errors != 2 {
+		t.Error("Expected 2 errors got", summary.Errors)
+	}
+}
+
+func TestLexFilesRecordsPositions(t *testing.T) {
+	root := writeSourceTree(t, map[string]string{"a.c": "int x;\n\ty = 1;\n"})
+
+	results, _ := LexFiles(context.Background(), []string{filepath.Join(root, "a.c")}, 0, WithTrivia(false), WithTabWidth(8))
+	expected := []Position{{1, 1, 0}, {1, 5, 4}, {1, 6, 5}, {2, 9, 8}, {2, 11, 10}, {2, 13, 12}, {2, 14, 13}}
+	if len(results[0].Positions) != len(expected) || len(results[0].Lexemes) != len(expected) {
+		t.Fatal("Expected", expected, "got", results[0].Positions, "for", results[0].Lexemes)
+	}
+	for i, p := range expected {
+		if results[0].Positions[i] != p {
+			t.Error("Expected", results[0].Lexemes[i], "at", p, "got", results[0].Positions[i])
+		}
+	}
+}
+
+func TestLexFilesStopsWhenCancelled(t *testing.T) {
+	root := writeSourceTree(t, map[string]string{"a.c": "int x;"})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	results, _ := LexFiles(ctx, []string{filepath.Join(root, "a.c")}, 1)
+	if results[0].Err != context.Canceled {
+		t.Error("Expected", context.Canceled, "got", results[0].Err)
+	}
+}
+
+func TestLexFilesFuncDeliversResultsInOrder(t *testing.T) {
+	files := map[string]string{}
+	var paths []string
+	for i := 0; i < 100; i++ {
+		name := "f" + strconv.Itoa(i) + ".c"
+		files[name] = strings.Repeat("int x;\n", 100-i)
+		paths = append(paths, name)
+	}
+	root := writeSourceTree(t, files)
+	for i := range paths {
+		paths[i] = filepath.Join(root, paths[i])
+	}
+
+	next := 0
+	summary := LexFilesFunc(context.Background(), paths, 3, func(result FileResult) {
+		if result.Path != paths[next] {
+			t.Error("Expected", paths[next], "got", result.Path)
+		}
+		next++
+
+		// Lexeme values don't share memory with the source.
+		value := result.Lexemes[0].Value
+		copy(result.Source, "xxx")
+		if value != "int" {
+			t.Error("Expected int got", value)
+		}
+	})
+	if next != len(paths) || summary.Files != len(paths) || summary.Lexemes == 0 {
+		t.Error("Expected", len(paths), "results got", next, summary)
+	}
+}
@@ -0,0 +1,158 @@
+package lex
+
+import (
+	"encoding/binary"
+	"strings"
+	"testing"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/denzel-morris/clex/lex/lexemes"
+)
+
+func addRoundTripSeeds(f *testing.F) {
+	for _, input := range roundTripInputs {
+		f.Add(input)
+	}
+	for _, fragment := range strings.Split(roundTripFragments, " ") {
+		f.Add(fragment)
+	}
+}
+
+// lexAll lexes rd, failing if it yields more lexemes than there are runes in
+// input, which means a lexeme consumed nothing and lexing would never end.
+// Alongside each lexeme it returns the position the reader ended up at.
+func lexAll(t *testing.T, input string, rd LineReader) ([]Lexeme, []Position) {
+	lexer := NewLexer(rd, discardErrorPolicy{}, WithRawBytes(true))
+	limit := len([]rune(input)) + 1
+
+	var lexemelist []Lexeme
+	var positions []Position
+	for {
+		lexeme, err := lexer.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if lexeme.Is(lexemes.EOF) {
+			return lexemelist, positions
+		}
+		if lexemelist = append(lexemelist, lexeme); len(lexemelist) > limit {
+			t.Fatalf("lexing %q doesn't terminate: %v", input, lexemelist[:limit])
+		}
+		positions = append(positions, rd.Position())
+	}
+}
+
+func concatenate(lexemelist []Lexeme) string {
+	var sb strings.Builder
+	for _, lexeme := range lexemelist {
+		sb.WriteString(lexeme.Value)
+	}
+	return sb.String()
+}
+
+// decodeWithBOM decodes input with the standard library as the lexer is
+// meant to: a UTF-8 byte order mark is dropped, UTF-16 with a byte order mark
+// is decoded with U+FFFD for an unpaired surrogate or a trailing odd byte,
+// and anything else is kept as it is.
+func decodeWithBOM(input string) string {
+	var order binary.ByteOrder
+	switch {
+	case strings.HasPrefix(input, "\xEF\xBB\xBF"):
+		return input[3:]
+	case strings.HasPrefix(input, "\xFF\xFE"):
+		order = binary.LittleEndian
+	case strings.HasPrefix(input, "\xFE\xFF"):
+		order = binary.BigEndian
+	default:
+		return input
+	}
+
+	var units []uint16
+	rest := []byte(input[2:])
+	for ; len(rest) >= 2; rest = rest[2:] {
+		units = append(units, order.Uint16(rest))
+	}
+	text := string(utf16.Decode(units))
+	if len(rest) == 1 {
+		text += string(utf8.RuneError)
+	}
+	return text
+}
+
+func FuzzLex(f *testing.F) {
+	addRoundTripSeeds(f)
+	f.Add("int a\xff;\n\"\xe2\x82\" // \xc3")
+	f.Fuzz(func(t *testing.T, input string) {
+		var chains [][]Position
+		for name, newLineReader := range lineReaderChains {
+			lexemelist, positions := lexAll(t, input, newLineReader(input))
+			if out := concatenate(lexemelist); out != input {
+				t.Fatalf("%s: lexemes %v of %q concatenate to %q", name, lexemelist, input, out)
+			}
+
+			offset, previous := 0, Position{Line: 1, Column: 1}
+			for i, position := range positions {
+				offset += len(lexemelist[i].Value)
+				if position.Offset != offset || position.Line < previous.Line ||
+					position.Line == previous.Line && position.Column <= previous.Column {
+					t.Fatalf("%s: position %v after %v follows %v in %q", name, position, lexemelist[i], previous, input)
+				}
+				previous = position
+			}
+			chains = append(chains, positions)
+		}
+
+		for i := range chains[0] {
+			if chains[0][i] != chains[1][i] {
+				t.Fatalf("reader chains disagree on position %v and %v after lexeme %d of %q", chains[0][i], chains[1][i], i, input)
+			}
+		}
+
+		// LexString decodes a source that starts with a byte order mark.
+		lexemelist, _ := LexString(input, WithRawBytes(true))
+		if out, decoded := concatenate(lexemelist), decodeWithBOM(input); out != decoded {
+			t.Fatalf("LexString: lexemes %v of %q concatenate to %q rather than %q", lexemelist, input, out, decoded)
+		}
+	})
+}
+
+// FuzzLineReaders drives lineReader and lookaheadLineReader through the same
+// reads, unreads and peeks, as directed by ops, comparing their positions
+// after each one.
+func FuzzLineReaders(f *testing.F) {
+	for _, input := range roundTripInputs {
+		f.Add(input, []byte{0, 0, 1, 0, 2, 0, 0, 1, 1, 0})
+	}
+	f.Fuzz(func(t *testing.T, input string, ops []byte) {
+		const lookahead = 4
+		rd1 := NewLineReader(NewLookaheadReader(strings.NewReader(input), lookahead))
+		rd2 := NewLookaheadLineReader(NewLookaheadReader(strings.NewReader(input), lookahead), lookahead)
+
+		unreadable := 0
+		for i, op := range ops {
+			var r1, r2 rune
+			switch op % 3 {
+			case 0:
+				r1, r2 = rd1.ReadRune(), rd2.ReadRune()
+				if r1 >= 0 {
+					unreadable = min(unreadable+1, lookahead-1)
+				}
+			case 1:
+				if unreadable == 0 {
+					continue
+				}
+				rd1.UnreadRune()
+				rd2.UnreadRune()
+				unreadable--
+			case 2:
+				r1, r2 = rd1.PeekRune(), rd2.PeekRune()
+			}
+
+			if r1 != r2 || rd1.Position() != rd2.Position() {
+				t.Fatalf("op %d of %v on %q: lineReader at %v read %q, lookaheadLineReader at %v read %q",
+					i, ops, input, rd1.Position(), r1, rd2.Position(), r2)
+			}
+		}
+	})
+}
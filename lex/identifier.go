@@ -0,0 +1,100 @@
+package lex
+
+import (
+	"strings"
+	"unicode"
+)
+
+// identifierRules decides which characters outside the basic character set
+// may appear in an identifier, and which of those may begin one.
+type identifierRules struct {
+	name    string
+	allowed func(rune) bool
+	initial func(rune) bool
+}
+
+var standardToIdentifierRules = map[Standard]identifierRules{
+	C11: {"C11 Annex D", isAnnexDAllowed, isAnnexDInitial},
+	C23: {"C23 XID_Start and XID_Continue", isXIDContinue, isXIDStart},
+}
+
+// annexD1 holds the ranges of characters allowed in identifiers by C11
+// Annex D.1.
+var annexD1 = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{0x00A8, 0x00A8, 1}, {0x00AA, 0x00AA, 1}, {0x00AD, 0x00AD, 1}, {0x00AF, 0x00AF, 1},
+		{0x00B2, 0x00B5, 1}, {0x00B7, 0x00BA, 1}, {0x00BC, 0x00BE, 1}, {0x00C0, 0x00D6, 1},
+		{0x00D8, 0x00F6, 1}, {0x00F8, 0x00FF, 1}, {0x0100, 0x167F, 1}, {0x1681, 0x180D, 1},
+		{0x180F, 0x1FFF, 1}, {0x200B, 0x200D, 1}, {0x202A, 0x202E, 1}, {0x203F, 0x2040, 1},
+		{0x2054, 0x2054, 1}, {0x2060, 0x206F, 1}, {0x2070, 0x218F, 1}, {0x2460, 0x24FF, 1},
+		{0x2776, 0x2793, 1}, {0x2C00, 0x2DFF, 1}, {0x2E80, 0x2FFF, 1}, {0x3004, 0x3007, 1},
+		{0x3021, 0x302F, 1}, {0x3031, 0x303F, 1}, {0x3040, 0xD7FF, 1}, {0xF900, 0xFD3D, 1},
+		{0xFD40, 0xFDCF, 1}, {0xFDF0, 0xFE44, 1}, {0xFE47, 0xFFFD, 1},
+	},
+	R32: []unicode.Range32{
+		{0x10000, 0x1FFFD, 1}, {0x20000, 0x2FFFD, 1}, {0x30000, 0x3FFFD, 1},
+		{0x40000, 0x4FFFD, 1}, {0x50000, 0x5FFFD, 1}, {0x60000, 0x6FFFD, 1},
+		{0x70000, 0x7FFFD, 1}, {0x80000, 0x8FFFD, 1}, {0x90000, 0x9FFFD, 1},
+		{0xA0000, 0xAFFFD, 1}, {0xB0000, 0xBFFFD, 1}, {0xC0000, 0xCFFFD, 1},
+		{0xD0000, 0xDFFFD, 1}, {0xE0000, 0xEFFFD, 1},
+	},
+}
+
+// annexD2 holds the ranges of characters C11 Annex D.2 disallows at the
+// start of an identifier, all of them combining marks.
+var annexD2 = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{0x0300, 0x036F, 1}, {0x1DC0, 0x1DFF, 1}, {0x20D0, 0x20FF, 1}, {0xFE20, 0xFE2F, 1},
+	},
+}
+
+func isAnnexDAllowed(r rune) bool { return unicode.Is(annexD1, r) }
+func isAnnexDInitial(r rune) bool { return unicode.Is(annexD1, r) && !unicode.Is(annexD2, r) }
+
+// isXIDStart and isXIDContinue approximate the Unicode XID_Start and
+// XID_Continue properties from the tables in package unicode, following the
+// definitions of ID_Start and ID_Continue in UAX #31. They differ from XID
+// only for the handful of characters whose NFKC form isn't an identifier.
+func isXIDStart(r rune) bool {
+	return (unicode.IsLetter(r) || unicode.In(r, unicode.Nl, unicode.Other_ID_Start)) && !isPatternCharacter(r)
+}
+
+func isXIDContinue(r rune) bool {
+	return isXIDStart(r) ||
+		unicode.In(r, unicode.Mn, unicode.Mc, unicode.Nd, unicode.Pc, unicode.Other_ID_Continue) && !isPatternCharacter(r)
+}
+
+func isPatternCharacter(r rune) bool {
+	return unicode.In(r, unicode.Pattern_Syntax, unicode.Pattern_White_Space)
+}
+
+// isExemptUniversalCharacter reports whether a universal character name may
+// designate r although it is below U+00A0, per C11 6.4.3p2.
+func isExemptUniversalCharacter(r rune) bool {
+	return r == '$' || r == '@' || r == '`'
+}
+
+// basicGraphicCharacters are the graphic characters of the basic source
+// character set of C11 5.2.1 besides letters and digits.
+const basicGraphicCharacters = `!"#%&'()*+,-./:;<=>?[\]^_{|}~`
+
+func isBasicSourceCharacter(r rune) bool {
+	switch {
+	case isNonDigit(r), isDecimalDigit(r), isWhitespace(r) && r != '\r':
+		return true
+	default:
+		return strings.ContainsRune(basicGraphicCharacters, r)
+	}
+}
+
+// basicCharacterToEscape spells the basic characters that are quotes,
+// backslashes or whitespace other than space as escape sequences.
+var basicCharacterToEscape = map[rune]string{
+	'"':  `\"`,
+	'\'': `\'`,
+	'\\': `\\`,
+	'\t': `\t`,
+	'\v': `\v`,
+	'\f': `\f`,
+	'\n': `\n`,
+}
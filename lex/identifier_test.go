@@ -0,0 +1,160 @@
+package lex
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/denzel-morris/clex/lex/lexemes"
+)
+
+func TestIdentifierRules(t *testing.T) {
+	cases := []struct {
+		input    string
+		standard Standard
+		expected Lexeme
+		code     Code
+	}{
+		{"x\u00B2", C11, Lexeme{lexemes.Identifier, "x\u00B2"}, CodeNone},
+		{"x\u00B2", C23, Lexeme{lexemes.Invalid, "x\u00B2"}, CodeInvalidIdentifierCharacter},
+		{"x\u2260y", C11, Lexeme{lexemes.Invalid, "x\u2260y"}, CodeInvalidIdentifierCharacter},
+		{"x\u00A0", C11, Lexeme{lexemes.Identifier, "x"}, CodeNone},
+		{"caf\u00E9", C23, Lexeme{lexemes.Identifier, "caf\u00E9"}, CodeNone},
+		{"a\u0300", C11, Lexeme{lexemes.Identifier, "a\u0300"}, CodeNone},
+		{"a\u0300", C23, Lexeme{lexemes.Identifier, "a\u0300"}, CodeNone},
+		{"\u0300a", C11, Lexeme{lexemes.Invalid, "\u0300a"}, CodeInvalidIdentifierStart},
+		{"\u0300a", C23, Lexeme{lexemes.Invalid, "\u0300a"}, CodeInvalidIdentifierStart},
+		{"\U0001F600", C11, Lexeme{lexemes.Identifier, "\U0001F600"}, CodeNone},
+		{"\u2118", C23, Lexeme{lexemes.Identifier, "\u2118"}, CodeNone},
+		{"\u0663x", C23, Lexeme{lexemes.Invalid, "\u0663x"}, CodeInvalidIdentifierStart},
+		{`\u00E0`, C11, Lexeme{lexemes.Identifier, `\u00E0`}, CodeNone},
+		{`\u0300a`, C11, Lexeme{lexemes.Invalid, `\u0300a`}, CodeInvalidIdentifierStart},
+		{`a\u00A9b`, C11, Lexeme{lexemes.Invalid, `a\u00A9b`}, CodeInvalidIdentifierCharacter},
+		{`\U0001F600`, C11, Lexeme{lexemes.Identifier, `\U0001F600`}, CodeNone},
+		{`\U0001F600`, C23, Lexeme{lexemes.Invalid, `\U0001F600`}, CodeInvalidIdentifierCharacter},
+		{`\u00B2`, C11, Lexeme{lexemes.Identifier, `\u00B2`}, CodeNone},
+		{`x\u00B2`, C23, Lexeme{lexemes.Invalid, `x\u00B2`}, CodeInvalidIdentifierCharacter},
+		{`\u0024`, C11, Lexeme{lexemes.Invalid, `\u0024`}, CodeInvalidIdentifierCharacter},
+	}
+
+	for _, c := range cases {
+		policy := &CollectingErrorPolicy{}
+		lexeme, _ := New(strings.NewReader(c.input), WithStandard(c.standard), WithErrorPolicy(policy)).Next()
+		if lexeme != c.expected {
+			t.Error("Expected", c.expected, "for", c.input, "in", c.standard, "got", lexeme)
+		}
+
+		ds := policy.Diagnostics()
+		switch {
+		case c.code == CodeNone && len(ds) > 0:
+			t.Error("Expected no diagnostics for", c.input, "in", c.standard, "got", ds)
+		case c.code != CodeNone && (len(ds) != 1 || ds[0].Code != c.code):
+			t.Error("Expected", c.code, "for", c.input, "in", c.standard, "got", ds)
+		}
+	}
+}
+
+func TestUniversalCharacterNameValues(t *testing.T) {
+	cases := []struct {
+		input    string
+		standard Standard
+		code     Code
+		message  string
+	}{
+		{`"\u0041"`, C11, CodeBasicCharacterUCN, `Universal character name \u0041 designates the basic character 'A', which must be written as is`},
+		{`'\u000A'`, C11, CodeBasicCharacterUCN, `Universal character name \u000A designates the basic character '\n', which must be written as is`},
+		{`"\u0007"`, C11, CodeInvalidUniversalCharacterName, `Universal character name \u0007 designates the control character '\a'`},
+		{`"\uD800"`, C11, CodeInvalidUniversalCharacterName, `Universal character name \uD800 designates a surrogate`},
+		{`"\U0000DFFF"`, C23, CodeInvalidUniversalCharacterName, `Universal character name \U0000DFFF designates a surrogate`},
+		{`"\U00110000"`, C23, CodeInvalidUniversalCharacterName, `Universal character name \U00110000 is beyond the last Unicode character`},
+		{`"\U00110000"`, C11, CodeNone, ""},
+		{`"\u0024\u0040\u0060\u00A0"`, C11, CodeNone, ""},
+	}
+
+	for _, c := range cases {
+		policy := &CollectingErrorPolicy{}
+		lexeme, _ := New(strings.NewReader(c.input), WithStandard(c.standard), WithErrorPolicy(policy)).Next()
+		ds := policy.Diagnostics()
+		if c.code == CodeNone {
+			if len(ds) != 0 || lexeme.Type != lexemes.StringLiteral {
+				t.Error("Expected", c.input, "to be valid in", c.standard, "got", lexeme, ds)
+			}
+			continue
+		}
+		if len(ds) != 1 || ds[0].Code != c.code || ds[0].Message != c.message || lexeme.Type != lexemes.Invalid {
+			t.Error("Expected", c.code, c.message, "for", c.input, "in", c.standard, "got", lexeme, ds)
+		}
+	}
+}
+
+func TestBasicCharacterUCNFix(t *testing.T) {
+	cases := []struct {
+		input string
+		fix   string
+		start int
+	}{
+		{`x\u0041y`, "A", 1},
+		{`x\u0030`, "0", 1},
+		{`x\u002By`, "", 1},
+		{`\u0030x`, "", 0},
+		{`x\u005Cy`, "", 1},
+		{`"a\u0022"`, `\"`, 2},
+		{`'\u005C'`, `\\`, 1},
+		{`"\u0009"`, `\t`, 1},
+	}
+
+	for _, c := range cases {
+		policy := &CollectingErrorPolicy{}
+		New(strings.NewReader(c.input), WithErrorPolicy(policy)).Next()
+		ds := policy.Diagnostics()
+		if c.fix == "" {
+			if len(ds) == 0 || ds[0].Code != CodeBasicCharacterUCN || len(ds[0].Fixes) != 0 {
+				t.Error("Expected no fix for", c.input, "got", ds)
+			}
+			continue
+		}
+		if len(ds) != 1 || len(ds[0].Fixes) != 1 {
+			t.Error("Expected a fix for", c.input, "got", ds)
+			continue
+		}
+		fix := ds[0].Fixes[0]
+		if fix.Text != c.fix || fix.Start.Offset != c.start || fix.End.Offset != c.start+6 {
+			t.Error("Expected", c.fix, "replacing", c.start, "to", c.start+6, "in", c.input, "got", fix.Text, fix.Start.Offset, fix.End.Offset)
+		}
+	}
+}
+
+func TestLexemeName(t *testing.T) {
+	spelled, _ := LexString(`\u00E9t\U000000E9 = "\u00E9"`)
+	written, _ := LexString("\u00E9t\u00E9")
+	if spelled[0].Name() != written[0].Name() || spelled[0].Name() != "\u00E9t\u00E9" {
+		t.Error("Expected", spelled[0], "and", written[0], "to have the same name, got", spelled[0].Name(), written[0].Name())
+	}
+	if spelled[0].Value != `\u00E9t\U000000E9` {
+		t.Error("Expected the spelling to be kept, got", spelled[0].Value)
+	}
+	if name := spelled[4].Name(); name != spelled[4].Value {
+		t.Error("Expected the name of", spelled[4], "to be its value, got", name)
+	}
+
+	decomposed, _ := LexString("e\u0301t\u00E9")
+	if decomposed[0].Name() == written[0].Name() || decomposed[0].NormalizedName() != written[0].NormalizedName() {
+		t.Error("Expected", decomposed[0], "and", written[0], "to have the same normalized name only")
+	}
+}
+
+func TestDecodeUniversalCharacterNames(t *testing.T) {
+	cases := map[string]string{
+		"plain":            "plain",
+		`a\u0062c`:         "abc",
+		`\U0001F600!`:      "\U0001F600!",
+		`\u00GG \u12 \x \`: `\u00GG \u12 \x \`,
+		`\\u0041`:          `\\u0041`,
+		`\\\u0041`:         `\\A`,
+	}
+
+	for input, expected := range cases {
+		if actual := DecodeUniversalCharacterNames(input); actual != expected {
+			t.Error("Expected", expected, "for", input, "got", actual)
+		}
+	}
+}
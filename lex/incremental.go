@@ -0,0 +1,339 @@
+package lex
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/denzel-morris/clex/lex/lexemes"
+)
+
+// Mode is the state of the lexer at a line boundary.
+type Mode int
+
+const (
+	// ModeNormal means a lexeme starts at the beginning of the line.
+	ModeNormal Mode = iota
+	// ModeWhitespace means the line begins inside a Whitespace lexeme.
+	ModeWhitespace
+	// ModeBlockComment means the line begins inside a block comment.
+	ModeBlockComment
+)
+
+var modeToName = map[Mode]string{
+	ModeNormal:       "normal",
+	ModeWhitespace:   "whitespace",
+	ModeBlockComment: "block-comment",
+}
+
+func (m Mode) String() string {
+	return modeToName[m]
+}
+
+func (m Mode) MarshalText() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+func (m *Mode) UnmarshalText(text []byte) error {
+	for mode, name := range modeToName {
+		if name == string(text) {
+			*m = mode
+			return nil
+		}
+	}
+	return fmt.Errorf("lex: unknown mode %q", text)
+}
+
+// Checkpoint records the state of the lexer at the start of a line. Lexeme
+// is the index of the lexeme that starts at, or contains, Offset.
+type Checkpoint struct {
+	Offset   int
+	Position Position
+	Mode     Mode
+	Lexeme   int
+}
+
+// Checkpoints returns a checkpoint for the start of every line of the source
+// lexemelist was lexed from.
+func Checkpoints(lexemelist []Lexeme) []Checkpoint {
+	return appendCheckpoints([]Checkpoint{{Position: Position{Line: 1, Column: 1}}}, lexemelist, 0, 0)
+}
+
+// appendCheckpoints appends the checkpoints of the lines that start within
+// lexemelist, whose first lexeme starts at offset and has index first.
+func appendCheckpoints(checkpoints []Checkpoint, lexemelist []Lexeme, offset, first int) []Checkpoint {
+	for i, lexeme := range lexemelist {
+		for j, r := range lexeme.Value {
+			if r != '\n' {
+				continue
+			}
+
+			start := offset + j + 1
+			checkpoint := Checkpoint{
+				Offset:   start,
+				Position: Position{Line: len(checkpoints) + 1, Column: 1, Offset: start},
+				Lexeme:   first + i,
+			}
+			switch {
+			case start == offset+len(lexeme.Value):
+				checkpoint.Mode, checkpoint.Lexeme = ModeNormal, first+i+1
+			case isBlockComment(lexeme):
+				checkpoint.Mode = ModeBlockComment
+			default:
+				checkpoint.Mode = ModeWhitespace
+			}
+			checkpoints = append(checkpoints, checkpoint)
+		}
+		offset += len(lexeme.Value)
+	}
+	return checkpoints
+}
+
+func isBlockComment(l Lexeme) bool {
+	return l.Is(lexemes.Comment) && strings.HasPrefix(l.Value, "/*")
+}
+
+// Edit replaces Length bytes of a source, starting at Offset, with Text.
+type Edit struct {
+	Offset, Length int
+	Text           string
+}
+
+// Relex returns the lexemes of the source old was lexed from after applying
+// edit. Rather than lexing the whole source again, it restarts at the
+// nearest checkpoint before the edit and stops as soon as a lexeme starts
+// where one of old started, past the edit: the lexer carries no state from
+// one lexeme to the next, so from there on the lexemes are unchanged.
+//
+// The values of old must add up to the source, so it must have been lexed
+// with trivia and, if the source may hold invalid UTF-8, raw bytes. Relex
+// itself lexes with both, whatever opts say, so that the result adds up to
+// the edited source in turn.
+//
+// Diagnostics are only reported for the part of the source that is lexed
+// again. Their positions are relative to the whole source.
+//
+// Relex computes the checkpoints of old, which takes time in proportion to
+// the source; RelexCheckpoints takes them instead.
+func Relex(old []Lexeme, edit Edit, opts ...Option) ([]Lexeme, error) {
+	lexemelist, _, err := RelexCheckpoints(old, Checkpoints(old), edit, opts...)
+	return lexemelist, err
+}
+
+// RelexCheckpoints is Relex given the checkpoints of old, from Checkpoints
+// or an earlier call, and also returns those of the result. Only the text
+// from the checkpoint it restarts at to where it stops is read; the other
+// lexemes are copied, and the checkpoints after the edit shifted.
+func RelexCheckpoints(old []Lexeme, checkpoints []Checkpoint, edit Edit, opts ...Option) ([]Lexeme, []Checkpoint, error) {
+	lexemelist, checkpoints, _, err := relex(old, checkpoints, edit, opts)
+	return lexemelist, checkpoints, err
+}
+
+const (
+	// relexWindow is how much of the source past the edit is lexed again
+	// at first. The window doubles until the lexemes resynchronize.
+	relexWindow = 4096
+	// relexLookahead is how far past the end of a lexeme the lexer may
+	// have read to find that end, so that a lexeme closer to the end of
+	// the window might be different in the whole source.
+	relexLookahead = minLookahead * utf8.UTFMax
+)
+
+func relex(old []Lexeme, checkpoints []Checkpoint, edit Edit, opts []Option) ([]Lexeme, []Checkpoint, int, error) {
+	if edit.Offset < 0 || edit.Length < 0 {
+		return nil, nil, 0, fmt.Errorf("lex: edit %+v out of range", edit)
+	}
+
+	restart := restartCheckpoint(checkpoints, edit.Offset)
+	origin := checkpoints[restart]
+	src := &editedSource{old: old, edit: edit, next: origin.Lexeme, offset: origin.Offset}
+
+	cfg := makeConfig(append(opts[:len(opts):len(opts)], WithTrivia(true), WithRawBytes(true), WithEncoding(UTF8)))
+	shifted := shiftedErrorPolicy{cfg.errors, origin.Position}
+	held := &CollectingErrorPolicy{}
+	cfg.errors = held
+
+	// The window is lexed again from the start each time it grows, but only
+	// the diagnostics of lexemes that weren't reached before are reported.
+	var window []Lexeme
+	resync, reported := -1, 0
+	editEnd := edit.Offset + len(edit.Text) - origin.Offset
+	for size := editEnd + relexWindow; ; size *= 2 {
+		more := src.fill(size)
+		if !more && src.offset < edit.Offset+edit.Length {
+			return nil, nil, 0, fmt.Errorf("lex: edit %+v out of range for a %d byte source", edit, src.offset)
+		}
+
+		text := src.text.String()
+		window, resync = window[:0], -1
+		truncated := false
+		offset, j := 0, 0
+		for lexeme, err := range newStringLexer(text, cfg).All() {
+			if offset >= editEnd {
+				for j < len(src.starts) && src.starts[j] < offset {
+					j++
+				}
+				if j < len(src.starts) && src.starts[j] == offset {
+					resync = src.first + j
+					break
+				}
+			}
+			if more && offset+len(lexeme.Value)+relexLookahead > len(text) {
+				truncated = true
+				break
+			}
+
+			if len(window) >= reported {
+				for _, d := range held.diagnostics {
+					shifted.ReportDiagnostic(d)
+				}
+				reported++
+				if err == nil {
+					err = shifted.Abort()
+				}
+			}
+			held.diagnostics = held.diagnostics[:0]
+			if err != nil {
+				return append(old[:origin.Lexeme:origin.Lexeme], window...), nil, len(window), err
+			}
+			window = append(window, lexeme)
+			offset += len(lexeme.Value)
+		}
+		held.diagnostics = held.diagnostics[:0]
+		if !truncated {
+			break
+		}
+	}
+
+	if resync < 0 {
+		lexemelist := append(old[:origin.Lexeme:origin.Lexeme], window...)
+		return lexemelist, appendCheckpoints(checkpoints[:restart+1:restart+1], window, origin.Offset, origin.Lexeme), len(window), nil
+	}
+
+	// Past the lexeme old resynchronizes at, the checkpoints of old hold
+	// but for where they are.
+	delta := len(edit.Text) - edit.Length
+	resyncOffset := origin.Offset + src.starts[resync-src.first] - delta
+	after := sort.Search(len(checkpoints), func(i int) bool { return checkpoints[i].Offset > resyncOffset })
+
+	lexemelist := make([]Lexeme, 0, origin.Lexeme+len(window)+len(old)-resync)
+	lexemelist = append(append(lexemelist, old[:origin.Lexeme]...), window...)
+	updated := make([]Checkpoint, 0, len(checkpoints)+strings.Count(edit.Text, "\n"))
+	updated = appendCheckpoints(append(updated, checkpoints[:restart+1]...), window, origin.Offset, origin.Lexeme)
+	shift := len(lexemelist) - resync
+	for _, checkpoint := range checkpoints[after:] {
+		checkpoint.Offset += delta
+		checkpoint.Position.Offset += delta
+		checkpoint.Position.Line = len(updated) + 1
+		checkpoint.Lexeme += shift
+		updated = append(updated, checkpoint)
+	}
+	return append(lexemelist, old[resync:]...), updated, len(window), nil
+}
+
+// restartCheckpoint returns the index of the last checkpoint at which lexing
+// can restart for an edit at offset. The checkpoint must start a lexeme
+// strictly before the edit: the whitespace that ends the line before it
+// could otherwise be extended by the edit.
+func restartCheckpoint(checkpoints []Checkpoint, offset int) int {
+	i := sort.Search(len(checkpoints), func(i int) bool { return checkpoints[i].Offset >= offset }) - 1
+	for i > 0 && checkpoints[i].Mode != ModeNormal {
+		i--
+	}
+	return max(i, 0)
+}
+
+// editedSource builds the text of a source with an edit applied, from the
+// lexeme of the source that a checkpoint starts, only as far as it is
+// needed.
+type editedSource struct {
+	old      []Lexeme
+	edit     Edit
+	text     strings.Builder
+	inserted bool
+
+	// next is the index of the next lexeme of old to add and offset where
+	// it starts in the source.
+	next, offset int
+
+	// starts holds where the lexemes added from the end of the edit on
+	// start in text; first is the index of the first of them.
+	starts []int
+	first  int
+}
+
+// fill adds lexemes of old until the text is at least size bytes long,
+// and reports whether any are left.
+func (s *editedSource) fill(size int) bool {
+	for s.text.Len() < size && s.next < len(s.old) {
+		s.add(s.old[s.next].Value)
+		s.next++
+	}
+	if !s.inserted && s.offset == s.edit.Offset {
+		s.text.WriteString(s.edit.Text)
+		s.inserted = true
+	}
+	return s.next < len(s.old)
+}
+
+// add adds the parts of value, a lexeme of old, that the edit doesn't
+// replace, inserting the text of the edit where it belongs.
+func (s *editedSource) add(value string) {
+	start, end := s.offset, s.offset+len(value)
+	editEnd := s.edit.Offset + s.edit.Length
+	s.offset = end
+
+	if !s.inserted && start >= s.edit.Offset {
+		s.text.WriteString(s.edit.Text)
+		s.inserted = true
+	}
+	if start >= editEnd {
+		if s.starts == nil {
+			s.first = s.next
+		}
+		s.starts = append(s.starts, s.text.Len())
+	}
+	if start < s.edit.Offset {
+		s.text.WriteString(value[:min(end, s.edit.Offset)-start])
+	}
+	if !s.inserted && end > s.edit.Offset {
+		s.text.WriteString(s.edit.Text)
+		s.inserted = true
+	}
+	if end > editEnd {
+		s.text.WriteString(value[max(start, editEnd)-start:])
+	}
+}
+
+// shiftedErrorPolicy moves diagnostics from a lexer started at a checkpoint
+// to where they are in the whole source. Checkpoints are at the start of a
+// line, so columns are unaffected.
+type shiftedErrorPolicy struct {
+	policy ErrorPolicy
+	origin Position
+}
+
+func (ep shiftedErrorPolicy) ReportError(message string, line string, position Position) {
+	ep.ReportDiagnostic(makeDiagnostic(message, line, position))
+}
+
+func (ep shiftedErrorPolicy) ReportDiagnostic(d Diagnostic) {
+	d.Start = ep.shift(d.Start)
+	d.Position = ep.shift(d.Position)
+	for i := range d.Fixes {
+		d.Fixes[i].Start = ep.shift(d.Fixes[i].Start)
+		d.Fixes[i].End = ep.shift(d.Fixes[i].End)
+	}
+	reportTo(ep.policy, d)
+}
+
+func (ep shiftedErrorPolicy) Abort() error {
+	return abortFrom(ep.policy)
+}
+
+func (ep shiftedErrorPolicy) shift(p Position) Position {
+	p.Line += ep.origin.Line - 1
+	p.Offset += ep.origin.Offset
+	return p
+}
@@ -0,0 +1,193 @@
+package lex
+
+import (
+	"encoding/json"
+	"math/rand"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/denzel-morris/clex/lex/lexemes"
+)
+
+func TestCheckpointsRecordLineStarts(t *testing.T) {
+	lexemelist, _ := New(strings.NewReader("a\n/* b\nc */ d\n\n  e")).Lex()
+
+	expected := []Checkpoint{
+		{Offset: 0, Position: Position{Line: 1, Column: 1}, Mode: ModeNormal, Lexeme: 0},
+		{Offset: 2, Position: Position{Line: 2, Column: 1, Offset: 2}, Mode: ModeNormal, Lexeme: 2},
+		{Offset: 7, Position: Position{Line: 3, Column: 1, Offset: 7}, Mode: ModeBlockComment, Lexeme: 2},
+		{Offset: 14, Position: Position{Line: 4, Column: 1, Offset: 14}, Mode: ModeWhitespace, Lexeme: 5},
+		{Offset: 15, Position: Position{Line: 5, Column: 1, Offset: 15}, Mode: ModeWhitespace, Lexeme: 5},
+	}
+
+	checkpoints := Checkpoints(lexemelist)
+	if len(checkpoints) != len(expected) {
+		t.Fatal("Expected", expected, "got", checkpoints)
+	}
+	for i := range checkpoints {
+		if checkpoints[i] != expected[i] {
+			t.Errorf("Expected %+v, got %+v", expected[i], checkpoints[i])
+		}
+	}
+}
+
+func TestCheckpointsAreSerializable(t *testing.T) {
+	checkpoint := Checkpoint{Offset: 7, Position: Position{Line: 3, Column: 1, Offset: 7}, Mode: ModeBlockComment, Lexeme: 2}
+	data, err := json.Marshal(checkpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"block-comment"`) {
+		t.Error("Expected the mode to be serialized by name, got", string(data))
+	}
+
+	var decoded Checkpoint
+	if err := json.Unmarshal(data, &decoded); err != nil || decoded != checkpoint {
+		t.Error("Expected", checkpoint, "after a round trip, got", decoded, err)
+	}
+}
+
+func TestRelexMatchesLexingFromScratch(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	fragments := strings.Split(roundTripFragments, " ")
+	fragments = append(fragments, " ", "*/", "/*", "//", "")
+
+	for _, input := range roundTripCorpus() {
+		old, _ := New(strings.NewReader(input)).Lex()
+
+		offset := runeBoundary(input, rnd.Intn(len(input)+1))
+		edit := Edit{
+			Offset: offset,
+			Length: runeBoundary(input, offset+rnd.Intn(len(input)-offset+1)) - offset,
+			Text:   fragments[rnd.Intn(len(fragments))],
+		}
+		edited := input[:edit.Offset] + edit.Text + input[edit.Offset+edit.Length:]
+
+		expected, _ := New(strings.NewReader(edited)).Lex()
+		lexemelist, err := Relex(old, edit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !equalLexemes(lexemelist, expected) {
+			t.Errorf("Relexing %q with %+v gave %v, expected %v", input, edit, lexemelist, expected)
+		}
+	}
+}
+
+func TestRelexStopsOnceResynchronized(t *testing.T) {
+	input := strings.Repeat("int x = 1;\n", 1000)
+	old, _ := New(strings.NewReader(input)).Lex()
+
+	offset := strings.Index(input[5000:], "x") + 5000
+	lexemelist, _, relexed, err := relex(old, Checkpoints(old), Edit{Offset: offset, Length: 1, Text: "yy"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if relexed > 8 {
+		t.Error("Expected only the edited line to be lexed again, lexed", relexed, "lexemes")
+	}
+	expected, _ := New(strings.NewReader(input[:offset] + "yy" + input[offset+1:])).Lex()
+	if !equalLexemes(lexemelist, expected) {
+		t.Error("Relexed lexemes differ from lexing from scratch")
+	}
+}
+
+func TestRelexReportsPositionsInWholeSource(t *testing.T) {
+	old, _ := New(strings.NewReader("a\nb\nc\n")).Lex()
+
+	policy := &CollectingErrorPolicy{}
+	lexemelist, _ := Relex(old, Edit{Offset: 4, Length: 1, Text: "$"}, WithErrorPolicy(policy))
+
+	diagnostics := policy.Diagnostics()
+	if len(diagnostics) != 1 {
+		t.Fatal("Expected 1 diagnostic, got", diagnostics)
+	}
+	if p := diagnostics[0].Start; p != (Position{Line: 3, Column: 1, Offset: 4}) {
+		t.Errorf("Expected the error at 3:1, got %+v", p)
+	}
+	if lexemelist[4] != (Lexeme{lexemes.Invalid, "$"}) {
+		t.Error("Expected Invalid{$}, got", lexemelist[4])
+	}
+}
+
+func TestRelexReportsEachDiagnosticOnceAcrossWindows(t *testing.T) {
+	input := "/*\n" + strings.Repeat("$ x\n", 3000) + "*/ y\n"
+	old, _ := New(strings.NewReader(input)).Lex()
+
+	expected := &CountingErrorPolicy{}
+	New(strings.NewReader(input[2:]), WithErrorPolicy(expected)).Lex()
+
+	policy := &CountingErrorPolicy{}
+	lexemelist, err := Relex(old, Edit{Offset: 0, Length: 2}, WithErrorPolicy(policy))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if policy.Count() != expected.Count() {
+		t.Error("Expected", expected.Count(), "diagnostics got", policy.Count())
+	}
+	if scratch, _ := New(strings.NewReader(input[2:])).Lex(); !equalLexemes(lexemelist, scratch) {
+		t.Error("Relexed lexemes differ from lexing from scratch")
+	}
+}
+
+func TestRelexCheckpointsKeepsCheckpointsUpToDate(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	fragments := []string{"", " ", "\n", "/*", "*/", "x", "\"", "//", "1.5e", "\t\n  "}
+
+	source := syntheticSource(400)
+	lexemelist, _ := New(strings.NewReader(source)).Lex()
+	checkpoints := Checkpoints(lexemelist)
+	for i := 0; i < 200; i++ {
+		offset := rnd.Intn(len(source) + 1)
+		edit := Edit{
+			Offset: offset,
+			Length: min(rnd.Intn(8), len(source)-offset),
+			Text:   fragments[rnd.Intn(len(fragments))],
+		}
+		source = source[:edit.Offset] + edit.Text + source[edit.Offset+edit.Length:]
+
+		var err error
+		lexemelist, checkpoints, err = RelexCheckpoints(lexemelist, checkpoints, edit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected, _ := New(strings.NewReader(source)).Lex()
+		if !equalLexemes(lexemelist, expected) {
+			t.Fatalf("Relexing with %+v gave %v, expected %v", edit, lexemelist, expected)
+		}
+		if expected := Checkpoints(expected); !equalCheckpoints(checkpoints, expected) {
+			t.Fatalf("Relexing with %+v gave checkpoints %+v, expected %+v", edit, checkpoints, expected)
+		}
+	}
+}
+
+func TestRelexRejectsEditsOutOfRange(t *testing.T) {
+	old, _ := New(strings.NewReader("int x;\n")).Lex()
+	for _, edit := range []Edit{{Offset: -1}, {Offset: 8}, {Offset: 5, Length: 3}} {
+		if _, err := Relex(old, edit); err == nil {
+			t.Error("Expected an error for", edit)
+		}
+	}
+}
+
+func equalCheckpoints(a, b []Checkpoint) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// runeBoundary moves offset back to the start of the rune containing it.
+func runeBoundary(s string, offset int) int {
+	for offset < len(s) && !utf8.RuneStart(s[offset]) {
+		offset--
+	}
+	return offset
+}
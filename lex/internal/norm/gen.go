@@ -0,0 +1,194 @@
+//go:build ignore
+
+// Gen writes tables.go from the UnicodeData.txt and CompositionExclusions.txt
+// of the Unicode Character Database:
+//
+//	go run gen.go -version 15.0.0
+//
+// Either file may be given as a local path instead of being downloaded.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"flag"
+	"fmt"
+	"go/format"
+	"io"
+	"iter"
+	"log"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+var (
+	version     = flag.String("version", "15.0.0", "Unicode `version` of the data")
+	unicodeData = flag.String("unicodedata", "", "read UnicodeData.txt from `path` rather than unicode.org")
+	exclusions  = flag.String("exclusions", "", "read CompositionExclusions.txt from `path` rather than unicode.org")
+	output      = flag.String("o", "tables.go", "write the tables to `file`")
+)
+
+func main() {
+	flag.Parse()
+
+	ucd := "https://www.unicode.org/Public/" + *version + "/ucd/"
+	mappings, combiningClasses := readUnicodeData(open(*unicodeData, ucd+"UnicodeData.txt"))
+	excluded := readExclusions(open(*exclusions, ucd+"CompositionExclusions.txt"))
+
+	decompositions := map[rune]string{}
+	for c := range mappings {
+		decompositions[c] = string(decompose(c, mappings))
+	}
+
+	// A character is a primary composite unless it is excluded, decomposes
+	// to a single character, or is or decomposes to a non-starter.
+	compositions := map[[2]rune]rune{}
+	for c, mapping := range mappings {
+		if len(mapping) == 2 && !excluded[c] && combiningClasses[c] == 0 && combiningClasses[mapping[0]] == 0 {
+			compositions[[2]rune(mapping)] = c
+		}
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// Code generated by gen.go from Unicode %s data; DO NOT EDIT.\n\n", *version)
+	fmt.Fprintf(&b, "package norm\n\n")
+	writeMap(&b, "decompositions maps characters to their full canonical decomposition,\n// except for Hangul syllables, which are decomposed algorithmically.", "decompositions", "rune", "string", decompositions, hex, strconv.QuoteToASCII, cmp.Compare[rune])
+	writeMap(&b, "combiningClasses maps characters to their canonical combining class\n// when it isn't zero.", "combiningClasses", "rune", "uint8", combiningClasses, hex, func(c uint8) string { return strconv.Itoa(int(c)) }, cmp.Compare[rune])
+	writeMap(&b, "compositions maps the pairs of characters that primary composites\n// decompose to, other than Hangul syllables, to the composites.", "compositions", "[2]rune", "rune", compositions, func(p [2]rune) string {
+		return fmt.Sprintf("{%s, %s}", hex(p[0]), hex(p[1]))
+	}, hex, func(p, q [2]rune) int {
+		return cmp.Or(cmp.Compare(p[0], q[0]), cmp.Compare(p[1], q[1]))
+	})
+
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(*output, src, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func open(path, url string) io.Reader {
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			log.Fatal(err)
+		}
+		return bytes.NewReader(data)
+	}
+
+	resp, err := http.Get(url)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		log.Fatal(url, ": ", resp.Status)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return bytes.NewReader(data)
+}
+
+// readUnicodeData reads the canonical decomposition mappings and the
+// non-zero canonical combining classes.
+func readUnicodeData(r io.Reader) (map[rune][]rune, map[rune]uint8) {
+	mappings := map[rune][]rune{}
+	combiningClasses := map[rune]uint8{}
+	for fields := range records(r, ";") {
+		c := parseCodePoint(fields[0])
+		if ccc, err := strconv.Atoi(fields[3]); err != nil {
+			log.Fatal(err)
+		} else if ccc != 0 {
+			combiningClasses[c] = uint8(ccc)
+		}
+		if fields[5] != "" && !strings.HasPrefix(fields[5], "<") {
+			mappings[c] = parseCodePoints(fields[5])
+		}
+	}
+	return mappings, combiningClasses
+}
+
+func readExclusions(r io.Reader) map[rune]bool {
+	excluded := map[rune]bool{}
+	for fields := range records(r, ";") {
+		excluded[parseCodePoint(fields[0])] = true
+	}
+	return excluded
+}
+
+func decompose(c rune, mappings map[rune][]rune) []rune {
+	mapping, ok := mappings[c]
+	if !ok {
+		return []rune{c}
+	}
+	var rs []rune
+	for _, m := range mapping {
+		rs = append(rs, decompose(m, mappings)...)
+	}
+	return rs
+}
+
+// records yields the fields of each line of r, ignoring comments and blank
+// lines.
+func records(r io.Reader, sep string) iter.Seq[[]string] {
+	return func(yield func([]string) bool) {
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() {
+			line, _, _ := strings.Cut(strings.TrimPrefix(scanner.Text(), "\uFEFF"), "#")
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			fields := strings.Split(line, sep)
+			for i := range fields {
+				fields[i] = strings.TrimSpace(fields[i])
+			}
+			if !yield(fields) {
+				return
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+func parseCodePoint(s string) rune {
+	c, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return rune(c)
+}
+
+func parseCodePoints(s string) []rune {
+	var rs []rune
+	for _, field := range strings.Fields(s) {
+		rs = append(rs, parseCodePoint(field))
+	}
+	return rs
+}
+
+func hex(c rune) string {
+	return fmt.Sprintf("0x%04X", c)
+}
+
+func writeMap[K comparable, V any](b *bytes.Buffer, doc, name, keyType, valueType string, m map[K]V, key func(K) string, value func(V) string, compare func(K, K) int) {
+	fmt.Fprintf(b, "// %s\nvar %s = map[%s]%s{\n", doc, name, keyType, valueType)
+	keys := make([]K, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.SortFunc(keys, compare)
+	for _, k := range keys {
+		fmt.Fprintf(b, "\t%s: %s,\n", key(k), value(m[k]))
+	}
+	fmt.Fprintf(b, "}\n\n")
+}
@@ -0,0 +1,37 @@
+package norm
+
+import "testing"
+
+func TestNFD(t *testing.T) {
+	cases := map[string]string{
+		"plain":         "plain",
+		"\u00E9t\u00E9": "e\u0301te\u0301",
+		"\u1E69":        "s\u0323\u0307",
+		"s\u0307\u0323": "s\u0323\u0307",
+		"\uAC01":        "\u1100\u1161\u11A8",
+	}
+	for input, expected := range cases {
+		if actual := NFD(input); actual != expected {
+			t.Errorf("Expected %+q got %+q", expected, actual)
+		}
+	}
+}
+
+func TestNFC(t *testing.T) {
+	cases := map[string]string{
+		"plain":              "plain",
+		"e\u0301te\u0301":    "\u00E9t\u00E9",
+		"s\u0307\u0323":      "\u1E69",
+		"a\u0301\u0301":      "\u00E1\u0301",
+		"e\u0327\u0301":      "\u0229\u0301",
+		"\u1100\u1161\u11A8": "\uAC01",
+		"\u0915\u093C":       "\u0915\u093C",
+		"\u212B":             "\u00C5",
+		"\u0041\u030A\u0301": "\u01FA",
+	}
+	for input, expected := range cases {
+		if actual := NFC(input); actual != expected {
+			t.Errorf("Expected %+q got %+q", expected, actual)
+		}
+	}
+}
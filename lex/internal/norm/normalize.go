@@ -0,0 +1,116 @@
+// Package norm puts strings in the canonical Unicode normalization forms,
+// NFD and NFC, as the identifier names of clex need.
+package norm
+
+//go:generate go run gen.go
+
+import (
+	"slices"
+	"unicode/utf8"
+)
+
+// Hangul syllables decompose into a leading consonant, a vowel and, for
+// all but the first of every hangulTCount syllables, a trailing consonant.
+const (
+	hangulSBase  = 0xAC00
+	hangulLBase  = 0x1100
+	hangulVBase  = 0x1161
+	hangulTBase  = 0x11A7
+	hangulLCount = 19
+	hangulVCount = 21
+	hangulTCount = 28
+	hangulNCount = hangulVCount * hangulTCount
+	hangulSCount = hangulLCount * hangulNCount
+)
+
+// NFD returns s in Normalization Form D: every character is replaced by its
+// canonical decomposition, and each run of combining marks is put in the
+// order of their canonical combining classes.
+func NFD(s string) string {
+	if isASCII(s) {
+		return s
+	}
+	return string(decompose(s))
+}
+
+// NFC returns s in Normalization Form C: s is decomposed as by NFD, then
+// each combining mark is composed with the starter before it unless
+// another mark of the same or a lower class comes between them.
+func NFC(s string) string {
+	if isASCII(s) {
+		return s
+	}
+
+	rs := decompose(s)
+	composed := rs[:0]
+	starter, lastClass := -1, uint8(0)
+	for _, r := range rs {
+		class := combiningClasses[r]
+		if starter >= 0 && (starter == len(composed)-1 || lastClass != 0 && lastClass < class) {
+			if c, ok := compose(composed[starter], r); ok {
+				composed[starter] = c
+				continue
+			}
+		}
+		if class == 0 {
+			starter = len(composed)
+		}
+		lastClass = class
+		composed = append(composed, r)
+	}
+	return string(composed)
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+func decompose(s string) []rune {
+	rs := make([]rune, 0, utf8.RuneCountInString(s))
+	for _, r := range s {
+		rs = appendDecomposition(rs, r)
+	}
+
+	for i := 0; i < len(rs); i++ {
+		j := i
+		for j < len(rs) && combiningClasses[rs[j]] != 0 {
+			j++
+		}
+		slices.SortStableFunc(rs[i:j], func(a, b rune) int {
+			return int(combiningClasses[a]) - int(combiningClasses[b])
+		})
+		i = j
+	}
+	return rs
+}
+
+func appendDecomposition(rs []rune, r rune) []rune {
+	if i := r - hangulSBase; i >= 0 && i < hangulSCount {
+		rs = append(rs, hangulLBase+i/hangulNCount, hangulVBase+i%hangulNCount/hangulTCount)
+		if t := i % hangulTCount; t != 0 {
+			rs = append(rs, hangulTBase+t)
+		}
+		return rs
+	}
+	if d, ok := decompositions[r]; ok {
+		return append(rs, []rune(d)...)
+	}
+	return append(rs, r)
+}
+
+// compose returns the primary composite a and b decompose to, if any.
+func compose(a, b rune) (rune, bool) {
+	if l, v := a-hangulLBase, b-hangulVBase; l >= 0 && l < hangulLCount && v >= 0 && v < hangulVCount {
+		return hangulSBase + (l*hangulVCount+v)*hangulTCount, true
+	}
+	if s, t := a-hangulSBase, b-hangulTBase; s >= 0 && s < hangulSCount && s%hangulTCount == 0 && t > 0 && t < hangulTCount {
+		return a + t, true
+	}
+	c, ok := compositions[[2]rune{a, b}]
+	return c, ok
+}
@@ -0,0 +1,98 @@
+package lex
+
+import (
+	"strings"
+	"sync"
+)
+
+// Symbol is a handle to a string in an Interner.
+type Symbol uint32
+
+// Interner is a symbol table of identifier and keyword names that may be
+// shared by lexers running concurrently, so that every occurrence of an
+// identifier carries the same string. Identifiers are interned by name, so
+// spellings with universal character names share a symbol with the
+// characters they designate. It is seeded with the keywords of every
+// Standard.
+type Interner struct {
+	mu      sync.RWMutex
+	symbols map[string]Symbol
+	strings []string
+
+	// introduced holds the Standard each keyword first appeared in, indexed
+	// by Symbol. Keywords are seeded first and it never changes afterwards.
+	introduced []Standard
+}
+
+func NewInterner() *Interner {
+	in := &Interner{symbols: make(map[string]Symbol)}
+	for _, standard := range []Standard{C11, C23} {
+		for _, keyword := range standardToKeywords[standard] {
+			if _, ok := in.symbols[keyword]; !ok {
+				in.add(keyword)
+				in.introduced = append(in.introduced, standard)
+			}
+		}
+	}
+	return in
+}
+
+// Intern returns the symbol for s, adding a copy of s to the table if it
+// isn't there yet.
+func (in *Interner) Intern(s string) Symbol {
+	sym, _ := in.intern(s)
+	return sym
+}
+
+func (in *Interner) intern(s string) (Symbol, string) {
+	in.mu.RLock()
+	sym, ok := in.symbols[s]
+	if ok {
+		s = in.strings[sym]
+	}
+	in.mu.RUnlock()
+	if ok {
+		return sym, s
+	}
+
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	if sym, ok = in.symbols[s]; !ok {
+		sym = in.add(strings.Clone(s))
+	}
+	return sym, in.strings[sym]
+}
+
+func (in *Interner) add(s string) Symbol {
+	sym := Symbol(len(in.strings))
+	in.symbols[s] = sym
+	in.strings = append(in.strings, s)
+	return sym
+}
+
+// Lookup returns the symbol for s without adding it.
+func (in *Interner) Lookup(s string) (Symbol, bool) {
+	in.mu.RLock()
+	defer in.mu.RUnlock()
+	sym, ok := in.symbols[s]
+	return sym, ok
+}
+
+// String returns the string sym was interned from.
+func (in *Interner) String(sym Symbol) string {
+	in.mu.RLock()
+	defer in.mu.RUnlock()
+	return in.strings[sym]
+}
+
+// Len returns the number of symbols, keywords included.
+func (in *Interner) Len() int {
+	in.mu.RLock()
+	defer in.mu.RUnlock()
+	return len(in.strings)
+}
+
+// IsKeyword reports whether sym is a keyword in the given standard.
+func (in *Interner) IsKeyword(sym Symbol, standard Standard) bool {
+	return int(sym) < len(in.introduced) && in.introduced[sym] <= standard
+}
@@ -0,0 +1,146 @@
+package lex
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"unsafe"
+
+	"github.com/denzel-morris/clex/lex/lexemes"
+)
+
+func TestInternerReturnsSameSymbol(t *testing.T) {
+	in := NewInterner()
+	sym := in.Intern("buffer_size")
+	if again := in.Intern(strings.Clone("buffer_size")); again != sym {
+		t.Error("Expected", sym, "got", again)
+	}
+	if s := in.String(sym); s != "buffer_size" {
+		t.Error("Expected buffer_size got", s)
+	}
+	if _, ok := in.Lookup("count"); ok {
+		t.Error("Expected count not to be interned")
+	}
+}
+
+func TestInternerIsSeededWithKeywords(t *testing.T) {
+	in := NewInterner()
+	if in.Len() != len(c23Keywords) {
+		t.Error("Expected", len(c23Keywords), "symbols got", in.Len())
+	}
+
+	cases := []struct {
+		keyword  string
+		standard Standard
+		expected bool
+	}{
+		{"while", C11, true},
+		{"while", C23, true},
+		{"typeof", C11, false},
+		{"typeof", C23, true},
+	}
+	for _, c := range cases {
+		sym, ok := in.Lookup(c.keyword)
+		if !ok || in.IsKeyword(sym, c.standard) != c.expected {
+			t.Error("Expected", c.keyword, "keyword in", c.standard, "to be", c.expected)
+		}
+	}
+	if sym := in.Intern("count"); in.IsKeyword(sym, C23) {
+		t.Error("Expected count not to be a keyword")
+	}
+}
+
+func TestInternerSharedAcrossLexers(t *testing.T) {
+	in := NewInterner()
+	sources := []string{"int count = count + 1;", "static int count;", "count--;"}
+	values := make([][]Lexeme, len(sources))
+
+	var wg sync.WaitGroup
+	for i, src := range sources {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			values[i], _ = New(strings.NewReader(src), WithInterner(in), WithTrivia(false)).Lex()
+		}()
+	}
+	wg.Wait()
+
+	var data *byte
+	for _, lexemelist := range values {
+		for _, lexeme := range lexemelist {
+			if lexeme.Value != "count" {
+				continue
+			}
+			if data == nil {
+				data = unsafe.StringData(lexeme.Value)
+			} else if unsafe.StringData(lexeme.Value) != data {
+				t.Error("Expected every count to share one string")
+			}
+		}
+	}
+	if in.Len() != len(c23Keywords)+1 {
+		t.Error("Expected one symbol besides keywords got", in.Len()-len(c23Keywords))
+	}
+}
+
+func TestInternedValuesDontAliasSource(t *testing.T) {
+	src := []byte("node int")
+	lexemelist, err := LexBytes(src, WithInterner(NewInterner()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	copy(src, "xxxx xxx")
+
+	expected := []Lexeme{
+		{lexemes.Identifier, "node"},
+		{lexemes.Whitespace, " "},
+		{lexemes.Keyword, "int"},
+	}
+	if !equalLexemes(lexemelist, expected) {
+		t.Error("Expected", expected, "got", lexemelist)
+	}
+}
+
+func TestInternerInternsIdentifierNames(t *testing.T) {
+	in := NewInterner()
+	lexemelist, _ := LexString("\\u00E9t = \u00E9t;", WithInterner(in), WithTrivia(false))
+
+	if lexemelist[0].Value != "\\u00E9t" {
+		t.Error("Expected the spelling to be kept, got", lexemelist[0].Value)
+	}
+	if in.Len() != len(c23Keywords)+1 {
+		t.Error("Expected both spellings to share a symbol, got", in.Len()-len(c23Keywords), "symbols")
+	}
+	if _, ok := in.Lookup("\u00E9t"); !ok {
+		t.Error("Expected the name to be interned")
+	}
+}
+
+func TestInternerClassifiesKeywords(t *testing.T) {
+	src := "typeof(bool) while_ = true; int x;"
+	for _, standard := range []Standard{C11, C23} {
+		expected, _ := LexString(src, WithStandard(standard))
+		lexemelist, _ := LexString(src, WithStandard(standard), WithInterner(NewInterner()))
+		if !equalLexemes(lexemelist, expected) {
+			t.Error("Expected", expected, "got", lexemelist, "in", standard)
+		}
+	}
+}
+
+func TestInternerInternsNormalizedNames(t *testing.T) {
+	src := "\u00E9t = e\u0301t;"
+	cases := []struct {
+		normalize bool
+		expected  int
+	}{
+		{false, 2},
+		{true, 1},
+	}
+	for _, c := range cases {
+		in := NewInterner()
+		LexString(src, WithInterner(in), WithNormalizedNames(c.normalize))
+		if symbols := in.Len() - len(c23Keywords); symbols != c.expected {
+			t.Error("Expected", c.expected, "symbols when normalize is", c.normalize, "got", symbols)
+		}
+	}
+}
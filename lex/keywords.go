@@ -1,5 +1,7 @@
 package lex
 
+import "sort"
+
 var keywords = []string{
 	"_Alignas", "_Alignof", "_Atomic", "_Bool", "_Complex", "_Generic",
 	"_Imaginary", "_Noreturn", "_Static_assert", "_Thread_local", "auto",
@@ -9,3 +11,34 @@ var keywords = []string{
 	"static", "struct", "switch", "typedef", "union", "unsigned", "void",
 	"volatile", "while",
 }
+
+var c23Keywords = sortedKeywords(keywords,
+	"_BitInt", "_Decimal128", "_Decimal32", "_Decimal64", "alignas",
+	"alignof", "bool", "constexpr", "false", "nullptr", "static_assert",
+	"thread_local", "true", "typeof", "typeof_unqual",
+)
+
+var standardToKeywords = map[Standard][]string{
+	C11: keywords,
+	C23: c23Keywords,
+}
+
+// keywordToStandard holds the Standard each keyword first appeared in. It
+// never changes, so lexers look keywords up in it without locking.
+var keywordToStandard = makeKeywordToStandard()
+
+func makeKeywordToStandard() map[string]Standard {
+	introduced := map[string]Standard{}
+	for _, standard := range []Standard{C23, C11} {
+		for _, keyword := range standardToKeywords[standard] {
+			introduced[keyword] = standard
+		}
+	}
+	return introduced
+}
+
+func sortedKeywords(base []string, extra ...string) []string {
+	all := append(append([]string(nil), base...), extra...)
+	sort.Strings(all)
+	return all
+}
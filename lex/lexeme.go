@@ -2,7 +2,10 @@ package lex
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 
+	"github.com/denzel-morris/clex/lex/internal/norm"
 	"github.com/denzel-morris/clex/lex/lexemes"
 )
 
@@ -29,3 +32,56 @@ func (l Lexeme) IsNot(typ lexemes.Type) bool {
 func (l Lexeme) Is(typ lexemes.Type) bool {
 	return l.Type == typ
 }
+
+// Name returns the name an Identifier lexeme denotes, its spelling with
+// universal character names decoded, so that identifiers spelled with and
+// without them compare equal. The names of other lexemes are their values.
+func (l Lexeme) Name() string {
+	if l.IsNot(lexemes.Identifier) {
+		return l.Value
+	}
+	return DecodeUniversalCharacterNames(l.Value)
+}
+
+// NormalizedName returns Name in Unicode Normalization Form C, so that
+// precomposed and decomposed spellings of a character, like U+00E9 and an e
+// followed by U+0301, compare equal too.
+func (l Lexeme) NormalizedName() string {
+	return norm.NFC(l.Name())
+}
+
+// DecodeUniversalCharacterNames replaces the universal character names in s
+// by the characters they designate. Malformed ones are kept as they are, and
+// so is an escaped backslash, so that s may also be the spelling of a string
+// or character literal: `\\u0041` there is a backslash followed by u0041.
+func DecodeUniversalCharacterNames(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		digits := 0
+		if s[i] == '\\' && i+1 < len(s) {
+			switch s[i+1] {
+			case '\\':
+				b.WriteString(`\\`)
+				i++
+				continue
+			case 'u':
+				digits = 4
+			case 'U':
+				digits = 8
+			}
+		}
+		if end := i + 2 + digits; digits > 0 && end <= len(s) {
+			if v, err := strconv.ParseUint(s[i+2:end], 16, 32); err == nil {
+				b.WriteRune(rune(v))
+				i = end - 1
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
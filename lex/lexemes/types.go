@@ -57,6 +57,7 @@ const (
 	DoubleGreaterThanEqual
 	AmpersandEqual
 	PipeEqual
+	CaretEqual
 	Hash
 	DoubleHash
 	Comma
@@ -117,6 +118,7 @@ var typeToName = map[Type]string{
 	DoubleGreaterThanEqual: "DoubleGreaterThanEqual",
 	AmpersandEqual:         "AmpersandEqual",
 	PipeEqual:              "PipeEqual",
+	CaretEqual:             "CaretEqual",
 	Hash:                   "Hash",
 	DoubleHash:             "DoubleHash",
 	Comma:                  "Comma",
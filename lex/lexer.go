@@ -2,7 +2,16 @@ package lex
 
 import (
 	"bytes"
-	"sort"
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
 
 	"github.com/denzel-morris/clex/lex/lexemes"
 )
@@ -10,19 +19,89 @@ import (
 type Lexer interface {
 	Lex() ([]Lexeme, error)
 	Next() (Lexeme, error)
+	All() iter.Seq2[Lexeme, error]
 }
 
-type lexer struct {
-	stream LineReader
-	buf    *bytes.Buffer
-	errors ErrorPolicy
+var ErrTooManyErrors = errors.New("lex: too many errors")
+
+// lexemeBuffer holds the runes of the lexeme being lexed.
+type lexemeBuffer interface {
+	WriteRune(r rune) (int, error)
+	WriteByte(c byte) error
+	Truncate(n int)
+	Len() int
+	String() string
+	Reset()
 }
 
-func NewLexer(rd LineReader, policy ErrorPolicy) Lexer {
+type lexer struct {
+	stream         LineReader
+	buf            lexemeBuffer
+	errors         ErrorPolicy
+	start          Position
+	standard       Standard
+	identifiers    identifierRules
+	identifierChar runeClass
+	// identifierStart is set until the first character of an identifier
+	// has been checked.
+	identifierStart bool
+	symbols         *Interner
+	normalize       bool
+	rawBytes        bool
+	bidiCheck       BidiCheck
+	bidiSeverity    Severity
+	bidiControls    []bidiControl
+	fileName        string
+	offsets         offsetMapper
+	trivia          bool
+	maxErrors       int
+	errorCount      int
+	err             error
+}
+
+// New returns a lexer reading from r, building the reader chain the lexer
+// needs according to opts.
+func New(r io.Reader, opts ...Option) Lexer {
+	cfg := makeConfig(opts)
+
+	scanner := newScanner(r, cfg)
+	source := sourceOf(scanner)
+	if source == nil {
+		source = sourceOf(r)
+	}
+	stream := newLookaheadLineReader(newLookaheadReaderWithSource(scanner, cfg.lookahead, source), cfg.lookahead, cfg.tabWidth)
+	l := newLexer(stream, cfg)
+	if d, ok := scanner.(*Decoder); ok {
+		l.offsets = d
+	}
+	return l
+}
+
+// NewLexer returns a lexer over an existing reader chain. The policy is
+// applied before opts, so that WithErrorPolicy overrides it.
+func NewLexer(rd LineReader, policy ErrorPolicy, opts ...Option) Lexer {
+	return newLexer(rd, makeConfig(append([]Option{WithErrorPolicy(policy)}, opts...)))
+}
+
+func newLexer(rd LineReader, cfg config) *lexer {
+	identifiers := standardToIdentifierRules[cfg.standard]
 	return &lexer{
-		stream: rd,
-		buf:    new(bytes.Buffer),
-		errors: policy,
+		identifiers: identifiers,
+		identifierChar: runeClassFunc(func(r rune) bool {
+			return isIdentifierChar(r) || r >= utf8.RuneSelf && !unicode.IsSpace(r) && !isUndecodable(r)
+		}),
+		stream:       rd,
+		buf:          new(bytes.Buffer),
+		errors:       cfg.errors,
+		standard:     cfg.standard,
+		symbols:      cfg.interner,
+		normalize:    cfg.normalize,
+		rawBytes:     cfg.rawBytes,
+		bidiCheck:    cfg.bidiCheck,
+		bidiSeverity: cfg.bidiSeverity,
+		fileName:     cfg.fileName,
+		trivia:       cfg.trivia,
+		maxErrors:    cfg.maxErrors,
 	}
 }
 
@@ -35,20 +114,125 @@ func (l *lexer) Lex() ([]Lexeme, error) {
 	return lexemelist, err
 }
 
+// All iterates over the remaining lexemes up to, but excluding, EOF. An
+// error ends the iteration after being yielded along with its lexeme.
+func (l *lexer) All() iter.Seq2[Lexeme, error] {
+	return func(yield func(Lexeme, error) bool) {
+		for {
+			lexeme, err := l.Next()
+			switch {
+			case err != nil:
+				yield(lexeme, err)
+				return
+			case lexeme.Is(lexemes.EOF):
+				return
+			case !yield(lexeme, nil):
+				return
+			}
+		}
+	}
+}
+
 func (l *lexer) Next() (Lexeme, error) {
+	if l.err != nil {
+		return makeLexeme(lexemes.EOF, ""), l.err
+	}
 	lexeme := l.next()
 	l.buf.Reset()
+	for !l.trivia && isTrivia(lexeme) && l.err == nil {
+		lexeme = l.next()
+		l.buf.Reset()
+	}
 	return lexeme, l.stream.Err()
 }
 
+func (l *lexer) SourceLine(line int) (string, bool) {
+	return l.stream.SourceLine(line)
+}
+
+// OriginalOffset maps an offset in a position to the offset in the source of
+// the byte it was decoded from.
+func (l *lexer) OriginalOffset(offset int) int {
+	if l.offsets == nil {
+		return offset
+	}
+	return l.offsets.OriginalOffset(offset)
+}
+
+// OriginalOffset maps the offset of a position reported by l, which counts
+// bytes of the decoded UTF-8 text, to the offset in the source of the byte
+// it was decoded from. The two differ when the source has a byte order mark
+// or isn't UTF-8.
+func OriginalOffset(l Lexer, offset int) int {
+	if mapper, ok := l.(offsetMapper); ok {
+		return mapper.OriginalOffset(offset)
+	}
+	return offset
+}
+
+// offsetMapper is implemented by what maps offsets in decoded text to
+// offsets in the source.
+type offsetMapper interface {
+	OriginalOffset(decoded int) int
+}
+
+// SourceLine returns the complete physical line numbered line of the source
+// l has read so far, for lexers that keep track of it.
+func SourceLine(l Lexer, line int) (string, bool) {
+	if source, ok := l.(interface{ SourceLine(int) (string, bool) }); ok {
+		return source.SourceLine(line)
+	}
+	return "", false
+}
+
+// LexemeStart returns the position at which the lexeme last returned by
+// l.Next starts, for lexers that keep track of it.
+func LexemeStart(l Lexer) (Position, bool) {
+	if starts, ok := l.(interface{ LexemeStart() Position }); ok {
+		return starts.LexemeStart(), true
+	}
+	return Position{}, false
+}
+
+func (l *lexer) LexemeStart() Position {
+	return l.start
+}
+
 func (l *lexer) next() Lexeme {
+	l.start = l.stream.Position()
 	typ := l.lex()
-	return l.makeLexeme(typ)
+	if typ == lexemes.Invalid {
+		l.recover()
+	}
+	if len(l.bidiControls) > 0 {
+		l.checkBidiControls(typ)
+	}
+	lexeme := l.makeLexeme(typ)
+	if l.symbols != nil && typ == lexemes.Identifier {
+		lexeme = l.intern(lexeme)
+	}
+	return lexeme
+}
+
+// recover skips the remainder of an invalid lexeme so that one mistake
+// yields one Invalid lexeme instead of a cascade of errors. An unterminated
+// literal extends to its closing quote or the end of the line; anything
+// else extends to the end of the word, stopping at whitespace, quotes and
+// punctuation.
+func (l *lexer) recover() {
+	value := l.value()
+	if i := strings.IndexAny(value, "\"'"); i >= 0 {
+		quote := rune(value[i])
+		l.consumeUntilDo(oneOf(string(quote)+"\n"), l.skipEscaped)
+		l.consume(oneRune(quote))
+		return
+	}
+	l.consumeWhile(wordChar)
 }
 
 func (l *lexer) lex() lexemes.Type {
 	switch r := l.peek(); {
-	case startsIdentifier(r), startsWideLiteral(r):
+	case l.startsIdentifier(r), startsWideLiteral(r):
 		return l.maybeKeyword(l.lexIdentifierOrLiteral())
 	case startsNumericConstant(r):
 		return l.lexNumericConstant()
@@ -62,11 +246,19 @@ func (l *lexer) lex() lexemes.Type {
 		return l.lexWhitespace()
 	case startsEOF(r):
 		return l.lexEOF()
+	case isUndecodable(r):
+		return l.lexInvalidBytes()
 	default:
 		return l.lexPunctuator()
 	}
 }
 
+// startsIdentifier also admits characters that may only continue an
+// identifier, so that beginning one with them is diagnosed precisely.
+func (l *lexer) startsIdentifier(r rune) bool {
+	return startsIdentifier(r) || r >= utf8.RuneSelf && l.identifiers.allowed(r)
+}
+
 func (l *lexer) lexIdentifierOrLiteral() lexemes.Type {
 	l.consume(oneOf("LUu"))
 	switch l.peek() {
@@ -86,23 +278,48 @@ func (l *lexer) lexIdentifierOrLiteral() lexemes.Type {
 }
 
 func (l *lexer) lexIdentifier() lexemes.Type {
-	ok := l.consumeWhileDo(identifierChar, l.lookForUnicodeEscape)
+	l.identifierStart = l.buf.Len() == 0
+	ok := l.consumeWhileDo(l.identifierChar, l.checkIdentifierChar)
 	if !ok {
 		return lexemes.Invalid
 	}
 	return lexemes.Identifier
 }
 
+// maybeKeyword classifies keywords when there is no interner; otherwise
+// intern does, with the same lookup that interns the name.
 func (l *lexer) maybeKeyword(typ lexemes.Type) lexemes.Type {
-	if isKeyword(l.value()) {
+	if l.symbols == nil && l.isKeyword(l.value()) {
 		return lexemes.Keyword
 	}
 	return typ
 }
 
-func isKeyword(str string) bool {
-	idx := sort.SearchStrings(keywords, str)
-	return idx < len(keywords) && keywords[idx] == str
+func (l *lexer) isKeyword(str string) bool {
+	introduced, ok := keywordToStandard[str]
+	return ok && introduced <= l.standard
+}
+
+// intern interns the name of the identifier lexeme, normalized if
+// WithNormalizedNames asks for it, making it a Keyword when the symbol is
+// one in the selected standard, and gives it the interned string when it is
+// spelled as is. Any other spelling is kept, but copied so that it doesn't
+// hold on to the source either.
+func (l *lexer) intern(lexeme Lexeme) Lexeme {
+	name := lexeme.Name()
+	if l.normalize {
+		name = lexeme.NormalizedName()
+	}
+	sym, interned := l.symbols.intern(name)
+	if name != lexeme.Value {
+		lexeme.Value = strings.Clone(lexeme.Value)
+		return lexeme
+	}
+	if l.symbols.IsKeyword(sym, l.standard) {
+		lexeme.Type = lexemes.Keyword
+	}
+	lexeme.Value = interned
+	return lexeme
 }
 
 func (l *lexer) lexNumericConstant() lexemes.Type {
@@ -115,7 +332,7 @@ func (l *lexer) lexNumericConstant() lexemes.Type {
 		typ = l.lexDecimalConstant()
 	}
 
-	if typ != lexemes.Invalid {
+	if typ == lexemes.IntegerConstant {
 		typ = l.lexNumericConstantSuffix()
 	}
 
@@ -148,14 +365,34 @@ func (l *lexer) lexOctalConstant() lexemes.Type {
 	return lexemes.IntegerConstant
 }
 
+// lexHexConstant lexes a hexadecimal integer constant or, when it has a
+// fractional part or a binary exponent, a hexadecimal floating constant
+// along with its suffix.
 func (l *lexer) lexHexConstant() lexemes.Type {
 	l.consume(oneOf("xX"))
-	ok := l.consumeAtLeastOne(hexDigit)
-	if !ok {
-		l.reportError("Hexadecimal constant must contain at least one digit")
+	digits := l.consumeAtLeastOne(hexDigit)
+	_, fractional := l.consume(decimalPoint)
+	if fractional && l.consumeAtLeastOne(hexDigit) {
+		digits = true
+	}
+	if !digits {
+		l.reportError(CodeMissingHexDigits, "Hexadecimal constant must contain at least one digit")
 		return lexemes.Invalid
 	}
-	return lexemes.IntegerConstant
+
+	switch r := l.peek(); {
+	case startsBinaryExponentPart(r):
+		if l.lexExponentPart(oneOf("pP")) == lexemes.Invalid {
+			return lexemes.Invalid
+		}
+		l.consume(oneOf("fFlL"))
+		return lexemes.FloatingConstant
+	case fractional:
+		l.reportErrorWithFix(CodeMissingBinaryExponent, "Hexadecimal floating constant must have a binary exponent", "Insert an exponent", "p0")
+		return lexemes.Invalid
+	default:
+		return lexemes.IntegerConstant
+	}
 }
 
 func (l *lexer) lexNumericConstantSuffix() lexemes.Type {
@@ -191,9 +428,12 @@ func (l *lexer) lexFloatingSuffix() lexemes.Type {
 		typ = lexemes.FloatingConstant
 		l.consume(decimalPoint)
 		l.consumeWhile(decimalDigit)
+		if l.value() == "." {
+			return typ
+		}
 		fallthrough
 	case startsExponentPart(r):
-		switch exponentTyp := l.lexExponentPart(); exponentTyp {
+		switch exponentTyp := l.lexExponentPart(oneOf("eE")); exponentTyp {
 		case lexemes.Invalid:
 			return exponentTyp
 		default:
@@ -204,22 +444,16 @@ func (l *lexer) lexFloatingSuffix() lexemes.Type {
 	return typ
 }
 
-func (l *lexer) lexExponentPart() lexemes.Type {
-	if _, ok := l.consume(oneOf("eEpP")); !ok {
+func (l *lexer) lexExponentPart(letters runeClass) lexemes.Type {
+	if _, ok := l.consume(letters); !ok {
 		return lexemes.FloatingConstant
 	}
-	_, hasSign := l.consume(oneOf("+-"))
+	l.consume(oneOf("+-"))
 	if l.consumeAtLeastOne(decimalDigit) {
 		return lexemes.FloatingConstant
 	}
 
-	if hasSign {
-		l.reportError("Exponent must have at least one digit")
-	} else if len(l.value()) == 2 {
-		l.buf.Truncate(l.buf.Len() - 1)
-		l.stream.UnreadRune()
-	}
-
+	l.reportErrorWithFix(CodeMissingExponentDigits, "Exponent must have at least one digit", "Insert a digit", "0")
 	return lexemes.Invalid
 }
 
@@ -242,14 +476,14 @@ func (l *lexer) lexStringLiteral() lexemes.Type {
 		return lexemes.Invalid
 	}
 
-	ok = l.consumeUntilDo(oneOf("\"\n"), l.lookForEscape)
+	ok = l.consumeWhileDo(notStringLiteralEnd, l.lookForEscape)
 	if !ok {
 		return lexemes.Invalid
 	}
 
 	_, ok = l.consume(oneRune('"'))
 	if !ok {
-		l.reportError("Expected `\"` to end string literal after newline")
+		l.reportErrorWithFix(CodeUnterminatedString, "Expected `\"` to end string literal after newline", "Insert `\"`", "\"")
 		return lexemes.Invalid
 	}
 	return lexemes.StringLiteral
@@ -258,13 +492,13 @@ func (l *lexer) lexStringLiteral() lexemes.Type {
 func (l *lexer) lexCharLiteral() lexemes.Type {
 	l.consume(oneRune('\''))
 
-	ok := l.consumeUntilDo(oneOf("'\n"), l.lookForEscape)
+	ok := l.consumeWhileDo(notCharLiteralEnd, l.lookForEscape)
 	if !ok {
 		return lexemes.Invalid
 	}
 	_, ok = l.consume(oneRune('\''))
 	if !ok {
-		l.reportError("Expected `'` to end character literal after newline")
+		l.reportErrorWithFix(CodeUnterminatedChar, "Expected `'` to end character literal after newline", "Insert `'`", "'")
 		return lexemes.Invalid
 	}
 	return lexemes.CharLiteral
@@ -284,31 +518,39 @@ func (l *lexer) lexCommentOrPunctuator() lexemes.Type {
 
 func (l *lexer) lexSingleLineComment() lexemes.Type {
 	l.consume(oneRune('/'))
-	l.consumeUntil(oneRune('\n'))
+	l.consumeWhile(notNewline)
 	return lexemes.Comment
 }
 
 func (l *lexer) lexMultiLineComment() lexemes.Type {
 	l.consume(oneRune('*'))
-	l.consumeWhileDo(any, l.lookForMultiLineCommentEnd)
+	if l.consumeWhileDo(any, l.lookForMultiLineCommentEnd) {
+		l.reportErrorWithFix(CodeUnterminatedComment, "Expected `*/` to end comment before end of file", "Insert `*/`", "*/")
+		return lexemes.Invalid
+	}
 	return lexemes.Comment
 }
 
+// lexPunctuator munches the longest punctuator, continuing from the "/" or
+// "." already consumed by the caller. It backs out of prefixes that aren't
+// punctuators themselves, like the final `%` of `%:%`.
 func (l *lexer) lexPunctuator() lexemes.Type {
-	tok := l.value() + string(l.peek())
-	for _, present := punctuatorToType[tok]; present; _, present = punctuatorToType[tok] {
+	state := punctuators.walk(l.value())
+	typ, accepted := punctuators[state].typ, l.buf.Len()
+	for next, ok := punctuators.step(state, l.peek()); ok; next, ok = punctuators.step(state, l.peek()) {
 		l.consume(any)
-		tok += string(l.peek())
+		if state = next; punctuators[state].typ != lexemes.Invalid {
+			typ, accepted = punctuators[state].typ, l.buf.Len()
+		}
 	}
-	typ := punctuatorToType[l.value()]
-	if typ == lexemes.Invalid && len(l.value()) <= 1 {
-		l.reportError("Unregonized character `" + l.value() + "`")
+
+	if typ == lexemes.Invalid && accepted == 0 {
+		l.consume(any)
+		l.reportError(CodeUnrecognizedCharacter, "Unregonized character `"+l.value()+"`")
 		return typ
 	}
 
-	// Backout until we find a valid punctuator
-	var present bool
-	for typ, present = punctuatorToType[l.value()]; present && typ == lexemes.Invalid; typ, present = punctuatorToType[l.value()] {
+	for l.buf.Len() > accepted {
 		l.buf.Truncate(l.buf.Len() - 1)
 		l.stream.UnreadRune()
 	}
@@ -321,16 +563,46 @@ func (l *lexer) lexWhitespace() lexemes.Type {
 	return lexemes.Whitespace
 }
 
+func (l *lexer) lexInvalidBytes() lexemes.Type {
+	l.consumeWhile(undecodable)
+	return lexemes.Invalid
+}
+
 func (l *lexer) lexEOF() lexemes.Type {
 	l.consume(any)
 	return lexemes.EOF
 }
 
-func (l *lexer) lookForUnicodeEscape(r rune) (cont bool) {
-	if startsEscape(r) {
-		return l.consumeUnicodeEscape()
+// checkIdentifierChar validates the character r just consumed into an
+// identifier, which may be the start of a universal character name. Any
+// character outside the basic character set is consumed, so that whether it
+// is written as is or as a universal character name, one that isn't allowed
+// is diagnosed the same way.
+func (l *lexer) checkIdentifierChar(r rune) (cont bool) {
+	first := l.identifierStart
+	l.identifierStart = false
+	switch {
+	case startsEscape(r):
+		r, cont = l.consumeUnicodeEscape(func(r rune) (string, bool) {
+			return string(r), isNonDigit(r) || !first && isDecimalDigit(r)
+		})
+		if !cont {
+			return false
+		}
+	case r < utf8.RuneSelf:
+		return true
 	}
-	return true
+
+	what := fmt.Sprintf("`%c` (U+%04X)", r, r)
+	switch {
+	case !l.identifiers.allowed(r):
+		l.reportError(CodeInvalidIdentifierCharacter, what+" is not allowed in an identifier by "+l.identifiers.name)
+	case first && !l.identifiers.initial(r):
+		l.reportError(CodeInvalidIdentifierStart, what+" is not allowed at the start of an identifier by "+l.identifiers.name)
+	default:
+		return true
+	}
+	return false
 }
 
 func (l *lexer) lookForEscape(r rune) (cont bool) {
@@ -340,6 +612,13 @@ func (l *lexer) lookForEscape(r rune) (cont bool) {
 	return true
 }
 
+func (l *lexer) skipEscaped(r rune) (cont bool) {
+	if startsEscape(r) {
+		l.consume(notNewline)
+	}
+	return true
+}
+
 func (l *lexer) lookForMultiLineCommentEnd(r rune) (cont bool) {
 	if r == '*' && l.peek() == '/' {
 		l.consume(oneRune('/'))
@@ -357,29 +636,81 @@ func (l *lexer) consumeEscape() (ok bool) {
 	case r == 'x':
 		ok = l.consumeHexEscape()
 	case r == 'u' || r == 'U':
-		ok = l.consumeUnicodeEscape()
+		_, ok = l.consumeUnicodeEscape(literalSpelling)
 	default:
-		l.reportError("Unknown character `" + string(r) + "` escaped")
+		l.reportErrorWithFix(CodeUnknownEscape, "Unknown character `"+string(r)+"` escaped", "Escape the backslash", "\\")
 	}
 	return ok
 }
 
-func (l *lexer) consumeUnicodeEscape() (ok bool) {
-	switch r, _ := l.consume(oneOf("uU")); r {
+// consumeUnicodeEscape consumes the universal character name following a
+// backslash, returning the character it designates. spelling returns how a
+// basic character is written directly in its place, if it can be without
+// changing the lexeme.
+func (l *lexer) consumeUnicodeEscape(spelling func(rune) (string, bool)) (r rune, ok bool) {
+	var digits int
+	switch u, _ := l.consume(oneOf("uU")); u {
 	case 'u':
-		ok = l.consumeN(hexDigits(4)) == 4
-		if !ok {
-			l.reportError("Expected 4 hexadecimal characters for universal character name")
-		}
+		digits = 4
 	case 'U':
-		ok = l.consumeN(hexDigits(8)) == 8
-		if !ok {
-			l.reportError("Expected 8 hexadecimal characters for universal character name")
-		}
+		digits = 8
+	default:
+		l.reportError(CodeExpectedUniversalCharacterName, "Expected universal character name starting with \\u or \\U")
+		return 0, false
+	}
+
+	if l.consumeN(hexDigits(digits)) != digits {
+		l.reportError(CodeIncompleteUniversalCharacterName, fmt.Sprintf("Expected %d hexadecimal characters for universal character name", digits))
+		return 0, false
+	}
+
+	value := l.value()
+	name := value[len(value)-digits-2:]
+	v, _ := strconv.ParseUint(name[2:], 16, 32)
+	switch r = rune(v); {
+	case isBasicSourceCharacter(r):
+		l.reportBasicCharacterUCN(name, r, spelling)
+	case r < 0xA0 && !isExemptUniversalCharacter(r):
+		l.reportError(CodeInvalidUniversalCharacterName, fmt.Sprintf("Universal character name %s designates the control character %q", name, r))
+	case utf16.IsSurrogate(r):
+		l.reportError(CodeInvalidUniversalCharacterName, "Universal character name "+name+" designates a surrogate")
+	case r > unicode.MaxRune && l.standard >= C23:
+		l.reportError(CodeInvalidUniversalCharacterName, "Universal character name "+name+" is beyond the last Unicode character")
 	default:
-		l.reportError("Expected universal character name starting with \\u or \\U")
+		return r, true
 	}
-	return ok
+	return r, false
+}
+
+// reportBasicCharacterUCN reports the universal character name just
+// consumed, which designates r from the basic source character set, along
+// with a fix spelling r directly where that keeps the lexeme as it is.
+func (l *lexer) reportBasicCharacterUCN(name string, r rune, spelling func(rune) (string, bool)) {
+	position := l.stream.Position()
+	start := position
+	start.Column -= len(name)
+	start.Offset -= len(name)
+
+	var fixes []Fix
+	if text, ok := spelling(r); ok {
+		fixes = []Fix{{Description: "Write `" + text + "`", Start: start, End: position, Text: text}}
+	}
+	l.report(Diagnostic{
+		Severity: SeverityError,
+		Code:     CodeBasicCharacterUCN,
+		File:     l.fileName,
+		Message:  fmt.Sprintf("Universal character name %s designates the basic character %q, which must be written as is", name, r),
+		Line:     l.stream.Line(),
+		Start:    l.start,
+		Position: position,
+		Fixes:    fixes,
+	})
+}
+
+// literalSpelling spells r in a string or character literal, escaping it if
+// need be.
+func literalSpelling(r rune) (string, bool) {
+	return cmp.Or(basicCharacterToEscape[r], string(r)), true
 }
 
 func (l *lexer) consumeSimpleEscape() (ok bool) {
@@ -395,7 +726,7 @@ func (l *lexer) consumeHexEscape() (ok bool) {
 	l.consume(oneRune('x'))
 	ok = l.consumeAtLeastOne(hexDigit)
 	if !ok {
-		l.reportError("Must provide at least one digit for hexadecimal escape")
+		l.reportError(CodeMissingHexEscapeDigits, "Must provide at least one digit for hexadecimal escape")
 	}
 	return ok
 }
@@ -457,13 +788,71 @@ func (l *lexer) consume(rc runeClass) (r rune, ok bool) {
 	includes := rc.has(r)
 	switch includes {
 	case true:
-		l.buf.WriteRune(r)
+		l.write(r)
 	case false:
 		l.stream.UnreadRune()
 	}
 	return r, includes
 }
 
+// write adds r to the lexeme. An escaped byte is reported, then written as
+// U+FFFD unless raw bytes are kept; an unpaired surrogate is always written
+// as U+FFFD. Bidirectional control characters are recorded to be checked once
+// the lexeme is complete.
+func (l *lexer) write(r rune) {
+	b, ok := unescapeByte(r)
+	switch {
+	case r > unicode.MaxRune:
+		unit, _ := unescapeSurrogate(r)
+		l.buf.WriteRune(utf8.RuneError)
+		l.reportUnpairedSurrogate(unit)
+		return
+	case !ok:
+		if isBidiControl(r) {
+			l.recordBidiControl(r)
+		}
+		l.buf.WriteRune(r)
+		return
+	case l.rawBytes:
+		l.buf.WriteByte(b)
+	default:
+		l.buf.WriteRune(utf8.RuneError)
+	}
+	l.reportInvalidByte(b)
+}
+
+func (l *lexer) reportInvalidByte(b byte) {
+	position := l.stream.Position()
+	position.Column--
+	position.Offset--
+
+	l.report(Diagnostic{
+		Severity: SeverityError,
+		Code:     CodeInvalidUTF8,
+		File:     l.fileName,
+		Message:  fmt.Sprintf("Invalid UTF-8 byte 0x%02X at offset %d", b, position.Offset),
+		Line:     l.stream.Line(),
+		Start:    position,
+		Position: position,
+	})
+}
+
+func (l *lexer) reportUnpairedSurrogate(unit rune) {
+	position := l.stream.Position()
+	position.Column--
+	position.Offset -= utf8.RuneLen(utf8.RuneError)
+
+	l.report(Diagnostic{
+		Severity: SeverityError,
+		Code:     CodeUnpairedSurrogate,
+		File:     l.fileName,
+		Message:  fmt.Sprintf("Unpaired UTF-16 surrogate 0x%04X at offset %d", unit, l.OriginalOffset(position.Offset)),
+		Line:     l.stream.Line(),
+		Start:    position,
+		Position: position,
+	})
+}
+
 func (l *lexer) peek() rune    { return l.stream.PeekRune() }
 func (l *lexer) value() string { return l.buf.String() }
 
@@ -471,7 +860,41 @@ func (l *lexer) makeLexeme(typ lexemes.Type) Lexeme {
 	return makeLexeme(typ, l.value())
 }
 
-func (l *lexer) reportError(message string) {
-	line, position := l.stream.Line(), l.stream.Position()
-	l.errors.ReportError(message, line, position)
+func (l *lexer) reportError(code Code, message string) {
+	l.reportErrorWithFix(code, message, "", "")
+}
+
+// reportErrorWithFix reports an error along with a suggestion to insert
+// text at the current position.
+func (l *lexer) reportErrorWithFix(code Code, message, description, text string) {
+	position := l.stream.Position()
+	var fixes []Fix
+	if description != "" {
+		fixes = []Fix{{Description: description, Start: position, End: position, Text: text}}
+	}
+
+	l.report(Diagnostic{
+		Severity: SeverityError,
+		Code:     code,
+		File:     l.fileName,
+		Message:  message,
+		Line:     l.stream.Line(),
+		Start:    l.start,
+		Position: position,
+		Fixes:    fixes,
+	})
+}
+
+func (l *lexer) report(d Diagnostic) {
+	if d.Severity == SeverityError {
+		l.errorCount++
+		if l.maxErrors > 0 && l.errorCount >= l.maxErrors {
+			l.err = ErrTooManyErrors
+		}
+	}
+
+	reportTo(l.errors, d)
+	if err := abortFrom(l.errors); err != nil {
+		l.err = err
+	}
 }
@@ -17,6 +17,11 @@ type partialMatchTestCase struct {
 	expected Lexeme
 }
 
+type streamTestCase struct {
+	input    string
+	expected []Lexeme
+}
+
 func TestLexerNext(t *testing.T) {
 	for _, c := range fullMatchTestCases {
 		lexer := makeLookaheadLexer(c.input, &EmptyErrorPolicy{})
@@ -44,8 +49,8 @@ func TestLexerNext(t *testing.T) {
 }
 
 func TestLexerLexicalErrors(t *testing.T) {
-	policy := &CountingErrorPolicy{}
 	for _, input := range errorTestCases {
+		policy := &CountingErrorPolicy{}
 		lexer := makeLookaheadLexer(input, policy)
 		lexeme, _ := lexer.Next()
 
@@ -53,19 +58,116 @@ func TestLexerLexicalErrors(t *testing.T) {
 			t.Error("Expected Invalid lexeme, got", lexeme, "on", input)
 		}
 
-		if policy.count == 0 {
-			t.Error("No lexical errors reported on", input)
+		if policy.Count() != 1 {
+			t.Error("Expected 1 lexical error on", input, "got", policy.Count())
+		}
+	}
+}
+
+func TestLexerRecoversAfterInvalidLexeme(t *testing.T) {
+	for _, c := range recoveryTestCases {
+		lexemelist, err := makeLookaheadLexer(c.input, &EmptyErrorPolicy{}).Lex()
+		if err != nil {
+			t.Error("On case:", c.input, "got error", err)
+		}
+
+		if !equalLexemes(lexemelist, c.expected) {
+			t.Error("Expected", c.expected, "got", lexemelist, "for", c.input)
+		}
+	}
+}
+
+func TestLexerStopsAfterMaxErrors(t *testing.T) {
+	policy := &CountingErrorPolicy{}
+	lexer := makeLookaheadLexer("$ $ $ $", policy, WithMaxErrors(2))
+
+	lexemelist, err := lexer.Lex()
+	if err != ErrTooManyErrors {
+		t.Error("Expected ErrTooManyErrors, got", err)
+	}
+
+	if policy.Count() != 2 {
+		t.Error("Expected 2 errors to be reported, got", policy.Count())
+	}
+
+	expected := []Lexeme{
+		{lexemes.Invalid, "$"},
+		{lexemes.Whitespace, " "},
+		{lexemes.Invalid, "$"},
+	}
+	if !equalLexemes(lexemelist, expected) {
+		t.Error("Expected", expected, "got", lexemelist)
+	}
+
+	if _, err := lexer.Next(); err != ErrTooManyErrors {
+		t.Error("Expected ErrTooManyErrors to persist, got", err)
+	}
+}
+
+func TestLexerAllStopsAtEOF(t *testing.T) {
+	var lexemelist []Lexeme
+	for lexeme, err := range makeLookaheadLexer("a+1", &EmptyErrorPolicy{}).All() {
+		if err != nil {
+			t.Error("Unexpected error", err)
+		}
+		lexemelist = append(lexemelist, lexeme)
+	}
+
+	expected := []Lexeme{
+		{lexemes.Identifier, "a"},
+		{lexemes.Plus, "+"},
+		{lexemes.IntegerConstant, "1"},
+	}
+	if !equalLexemes(lexemelist, expected) {
+		t.Error("Expected", expected, "got", lexemelist)
+	}
+}
+
+func TestLexerAllSupportsEarlyBreak(t *testing.T) {
+	lexer := makeLookaheadLexer("a b", &EmptyErrorPolicy{})
+	for range lexer.All() {
+		break
+	}
+
+	lexeme, _ := lexer.Next()
+	if lexeme.Type != lexemes.Whitespace {
+		t.Error("Expected lexing to resume after break, got", lexeme)
+	}
+}
+
+func TestLexerAllYieldsErrors(t *testing.T) {
+	var errs []error
+	for _, err := range makeLookaheadLexer("$ $", &FailFastErrorPolicy{}).All() {
+		if err != nil {
+			errs = append(errs, err)
 		}
 	}
+
+	if len(errs) != 1 {
+		t.Error("Expected exactly one error, got", errs)
+	}
 }
 
-func makeLookaheadLexer(input string, policy ErrorPolicy) Lexer {
+func makeLookaheadLexer(input string, policy ErrorPolicy, opts ...Option) Lexer {
 	return NewLexer(
 		NewLookaheadLineReader(NewLookaheadReader(strings.NewReader(input), 4), 4),
 		policy,
+		opts...,
 	)
 }
 
+func equalLexemes(a, b []Lexeme) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
 type EmptyErrorPolicy struct{}
 
 func (ep EmptyErrorPolicy) ReportError(message string, line string, position Position) {}
@@ -78,23 +180,16 @@ func (ep LogErrorPolicy) ReportError(message string, line string, position Posit
 	ep.t.Log(message, line, position)
 }
 
-type CountingErrorPolicy struct {
-	count int
-}
-
-func (ep *CountingErrorPolicy) ReportError(message string, line string, position Position) {
-	ep.count++
-}
-
 var fullMatchTestCases = []fullMatchTestCase{
 	{"a", lexemes.Identifier},
 	{"B", lexemes.Identifier},
 	{"Cd", lexemes.Identifier},
 	{"E1f", lexemes.Identifier},
 	{`\u12Ab`, lexemes.Identifier},
-	{`\U5678CdEf`, lexemes.Identifier},
+	{`\U0001F600`, lexemes.Identifier},
 	{`\u90AB4gh5`, lexemes.Identifier},
-	{`\U1234567890ij6`, lexemes.Identifier},
+	{`\U0001234590ij6`, lexemes.Identifier},
+	{`\U5678CdEf`, lexemes.Invalid},
 	{"u8", lexemes.Identifier},
 	{"u8ab", lexemes.Identifier},
 	{"auto", lexemes.Keyword},
@@ -183,6 +278,9 @@ var fullMatchTestCases = []fullMatchTestCase{
 	{"0x0123ABCDEF.p01", lexemes.FloatingConstant},
 	{"0xa.p1F", lexemes.FloatingConstant},
 	{"0xAbCdp-1L", lexemes.FloatingConstant},
+	{"0x.8p1", lexemes.FloatingConstant},
+	{"0xA.Bp-3", lexemes.FloatingConstant},
+	{"0x1.fffffep127L", lexemes.FloatingConstant},
 	{"'a'", lexemes.CharLiteral},
 	{"'bc'", lexemes.CharLiteral},
 	{`'\''`, lexemes.CharLiteral},
@@ -304,6 +402,7 @@ var fullMatchTestCases = []fullMatchTestCase{
 	{">>=", lexemes.DoubleGreaterThanEqual},
 	{"&=", lexemes.AmpersandEqual},
 	{"|=", lexemes.PipeEqual},
+	{"^=", lexemes.CaretEqual},
 	{"#", lexemes.Hash},
 	{"##", lexemes.DoubleHash},
 	{"<:", lexemes.LeftBracket},
@@ -325,6 +424,58 @@ var partialMatchTestCases = []partialMatchTestCase{
 	{".extended", Lexeme{lexemes.Period, "."}},
 }
 
+var recoveryTestCases = []streamTestCase{
+	{`"\z abc" x`, []Lexeme{
+		{lexemes.Invalid, `"\z abc"`},
+		{lexemes.Whitespace, " "},
+		{lexemes.Identifier, "x"},
+	}},
+	{`'\z' c`, []Lexeme{
+		{lexemes.Invalid, `'\z'`},
+		{lexemes.Whitespace, " "},
+		{lexemes.Identifier, "c"},
+	}},
+	{`L"\q\"" y`, []Lexeme{
+		{lexemes.Invalid, `L"\q\""`},
+		{lexemes.Whitespace, " "},
+		{lexemes.Identifier, "y"},
+	}},
+	{"0x; y", []Lexeme{
+		{lexemes.Invalid, "0x"},
+		{lexemes.SemiColon, ";"},
+		{lexemes.Whitespace, " "},
+		{lexemes.Identifier, "y"},
+	}},
+	{"$abc+1", []Lexeme{
+		{lexemes.Invalid, "$abc"},
+		{lexemes.Plus, "+"},
+		{lexemes.IntegerConstant, "1"},
+	}},
+	{`\u00zz b`, []Lexeme{
+		{lexemes.Invalid, `\u00zz`},
+		{lexemes.Whitespace, " "},
+		{lexemes.Identifier, "b"},
+	}},
+	{"2E+ q", []Lexeme{
+		{lexemes.Invalid, "2E+"},
+		{lexemes.Whitespace, " "},
+		{lexemes.Identifier, "q"},
+	}},
+	{"2Ex.y", []Lexeme{
+		{lexemes.Invalid, "2Ex.y"},
+	}},
+	{"\"hello\nx", []Lexeme{
+		{lexemes.Invalid, `"hello`},
+		{lexemes.Whitespace, "\n"},
+		{lexemes.Identifier, "x"},
+	}},
+	{"a 2E", []Lexeme{
+		{lexemes.Identifier, "a"},
+		{lexemes.Whitespace, " "},
+		{lexemes.Invalid, "2E"},
+	}},
+}
+
 var errorTestCases = []string{
 	`\u000`,
 	`\U0000000`,
@@ -338,5 +489,14 @@ var errorTestCases = []string{
 	"\"hello\n",
 	"'h\n",
 	"2E",
+	"1e",
+	"1.e",
+	"1e+",
+	"1.5E-",
+	"2Ex.y",
 	"0x",
+	"0x.p1",
+	"0x1.8",
+	"0x1.p",
+	"/* open",
 }
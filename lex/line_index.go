@@ -0,0 +1,174 @@
+package lex
+
+import (
+	"io"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// lineIndex records the offset at which each line starts, so that complete
+// lines can be read back from the source after the fact. When the source
+// supports reading its text back, only the text of the last few lines, which
+// the reader may still unread into, is kept, and the lines before them are
+// read from the source; otherwise the text of every line is kept. Offsets
+// are in bytes of the UTF-8 encoded text, in which an escaped byte is kept as
+// the byte itself.
+type lineIndex struct {
+	starts   []int
+	tabWidth int
+
+	// text holds the source from offset base on, which starts the line keep
+	// lines before the last one read.
+	text   []byte
+	base   int
+	keep   int
+	source textSource
+}
+
+// textSource is implemented by readers that can read back the text between
+// two offsets of what they have read.
+type textSource interface {
+	readText(start, end int) (string, bool)
+}
+
+// readerAtSource reads back text from a source in which offsets are the
+// offsets of the underlying bytes.
+type readerAtSource struct {
+	io.ReaderAt
+}
+
+func (s readerAtSource) readText(start, end int) (string, bool) {
+	buf := make([]byte, end-start)
+	n, _ := s.ReadAt(buf, int64(start))
+	return string(buf[:n]), n == len(buf)
+}
+
+// sourceOf returns the text source of r, if it has one.
+func sourceOf(r interface{}) textSource {
+	switch r := r.(type) {
+	case textSource:
+		return r
+	case io.ReaderAt:
+		return readerAtSource{r}
+	}
+	return nil
+}
+
+func newLineIndex(tabWidth, keep int, source textSource) *lineIndex {
+	return &lineIndex{starts: []int{0}, tabWidth: tabWidth, keep: max(keep, 1), source: source}
+}
+
+// advance returns the position following the rune r read at p.
+func (ix *lineIndex) advance(p Position, r rune) Position {
+	p.Offset = ix.extend(p.Offset, r)
+	switch r {
+	case '\n':
+		p.Column = 1
+		p.Line++
+	default:
+		p.Column = ix.nextColumn(p.Column, r)
+	}
+	return p
+}
+
+func (ix *lineIndex) nextColumn(column int, r rune) int {
+	return nextColumn(column, r, ix.tabWidth)
+}
+
+// nextColumn returns the column following the rune r read at column, with
+// tabs advancing to the next tab stop when tabWidth is greater than one.
+func nextColumn(column int, r rune, tabWidth int) int {
+	if r == '\t' && tabWidth > 1 {
+		return (column-1)/tabWidth*tabWidth + tabWidth + 1
+	}
+	return column + 1
+}
+
+func (ix *lineIndex) end() int {
+	return ix.base + len(ix.text)
+}
+
+// extend records r as the rune at offset, unless it was recorded before
+// (e.g. it is being read again after being unread). It returns the offset
+// of the following rune.
+func (ix *lineIndex) extend(offset int, r rune) int {
+	if offset < ix.end() {
+		_, size := utf8.DecodeRune(ix.text[offset-ix.base:])
+		return offset + size
+	}
+
+	if b, ok := unescapeByte(r); ok {
+		ix.text = append(ix.text, b)
+	} else {
+		ix.text = utf8.AppendRune(ix.text, r)
+	}
+	if r == '\n' {
+		ix.starts = append(ix.starts, ix.end())
+		ix.forget()
+	}
+	return ix.end()
+}
+
+// forget drops the text of the lines that can no longer be unread into, if
+// it can be read back from the source.
+func (ix *lineIndex) forget() {
+	if ix.source == nil || len(ix.starts) <= ix.keep+1 {
+		return
+	}
+	base := ix.starts[len(ix.starts)-1-ix.keep]
+	ix.text = append(ix.text[:0], ix.text[base-ix.base:]...)
+	ix.base = base
+}
+
+// lastRune returns the rune ending at offset and its size.
+func (ix *lineIndex) lastRune(offset int) (rune, int) {
+	return utf8.DecodeLastRune(ix.text[:offset-ix.base])
+}
+
+// position computes the position of the rune at offset.
+func (ix *lineIndex) position(offset int) Position {
+	line := sort.SearchInts(ix.starts, offset+1)
+	column := 1
+	for _, r := range string(ix.text[ix.starts[line-1]-ix.base : offset-ix.base]) {
+		column = ix.nextColumn(column, r)
+	}
+	return Position{Line: line, Column: column, Offset: offset}
+}
+
+// prefix returns the part of the line containing offset that precedes it.
+func (ix *lineIndex) prefix(p Position) string {
+	start := ix.starts[p.Line-1]
+	if start < ix.base {
+		return ""
+	}
+	return string(ix.text[start-ix.base : p.Offset-ix.base])
+}
+
+// line returns the complete physical line numbered n, without its line
+// terminator. Every line that has been read is available; the last one may
+// still be incomplete.
+func (ix *lineIndex) line(n int) (string, bool) {
+	if n < 1 || n > len(ix.starts) {
+		return "", false
+	}
+
+	start, end := ix.starts[n-1], ix.end()
+	if n < len(ix.starts) {
+		end = ix.starts[n] - 1
+	}
+
+	var line string
+	switch {
+	case start >= ix.base:
+		line = string(ix.text[start-ix.base : end-ix.base])
+	case ix.source != nil:
+		var ok bool
+		if line, ok = ix.source.readText(start, end); !ok {
+			return "", false
+		}
+	default:
+		return "", false
+	}
+	return strings.TrimSuffix(line, "\r"), true
+}
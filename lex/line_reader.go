@@ -1,26 +1,38 @@
 package lex
 
-import "bytes"
-
 type LineReader interface {
 	Reader
 	Position() Position
 	Line() string
+	SourceLine(line int) (string, bool)
 }
 
 type lineReader struct {
-	reader                 Reader
-	position, lastPosition Position
-	lineBuf                *bytes.Buffer
+	reader   Reader
+	position Position
+	index    *lineIndex
 }
 
 func NewLineReader(rd Reader) LineReader {
+	keep, source := readerSource(rd)
 	return &lineReader{
-		reader:       rd,
-		position:     Position{Line: 1, Column: 1},
-		lastPosition: Position{Line: 1, Column: 1},
-		lineBuf:      new(bytes.Buffer),
+		reader:   rd,
+		position: Position{Line: 1, Column: 1},
+		index:    newLineIndex(1, keep, source),
+	}
+}
+
+// readerSource returns how many runes rd can unread, which bounds how many
+// lines back a line reader over it can go, and where the text read from it
+// can be read back from.
+func readerSource(rd Reader) (unread int, source textSource) {
+	switch rd := rd.(type) {
+	case *lookaheadReader:
+		return int(rd.lookahead), rd.source
+	case *reader:
+		return 1, sourceOf(rd.scanner)
 	}
+	return 1, nil
 }
 
 func (rd *lineReader) Position() Position {
@@ -28,7 +40,11 @@ func (rd *lineReader) Position() Position {
 }
 
 func (rd *lineReader) Line() string {
-	return rd.lineBuf.String()
+	return rd.index.prefix(rd.position)
+}
+
+func (rd *lineReader) SourceLine(line int) (string, bool) {
+	return rd.index.line(line)
 }
 
 func (rd *lineReader) PeekRune() rune {
@@ -36,15 +52,16 @@ func (rd *lineReader) PeekRune() rune {
 }
 
 func (rd *lineReader) ReadRune() rune {
-	rd.savePosition()
 	r := rd.reader.ReadRune()
-	rd.updateLineAndPosition(r)
+	rd.updatePosition(r)
 	return r
 }
 
 func (rd *lineReader) UnreadRune() {
+	if rd.position.Offset == 0 {
+		return
+	}
 	rd.restorePosition()
-	rd.unreadRune()
 	rd.reader.UnreadRune()
 }
 
@@ -52,38 +69,19 @@ func (rd *lineReader) Err() error {
 	return rd.reader.Err()
 }
 
-func (rd *lineReader) savePosition() {
-	rd.lastPosition = rd.position
-}
-
-func (rd *lineReader) restorePosition() {
-	rd.position = rd.lastPosition
-}
-
-func (rd *lineReader) updateLineAndPosition(r rune) {
-	rd.updateLine(r)
-	rd.updatePosition(r)
-}
-
-func (rd *lineReader) updateLine(r rune) {
-	switch {
-	case r > 0:
-		rd.lineBuf.WriteRune(r)
-	case r == '\n':
-		rd.lineBuf.Reset()
-	}
-}
-
 func (rd *lineReader) updatePosition(r rune) {
-	switch {
-	case r > 0:
-		rd.position.Column++
-	case r == '\n':
-		rd.position.Column = 1
-		rd.position.Line++
+	if r >= 0 {
+		rd.position = rd.index.advance(rd.position, r)
 	}
 }
 
-func (rd *lineReader) unreadRune() {
-	rd.lineBuf.Truncate(rd.lineBuf.Len() - 1)
+func (rd *lineReader) restorePosition() {
+	r, size := rd.index.lastRune(rd.position.Offset)
+	switch r {
+	case '\n':
+		rd.position = rd.index.position(rd.position.Offset - size)
+	default:
+		rd.position.Column--
+		rd.position.Offset -= size
+	}
 }
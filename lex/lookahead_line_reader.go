@@ -1,23 +1,26 @@
 package lex
 
 import (
-	"bytes"
-
 	"github.com/denzel-morris/clex/lex/container"
 )
 
 type lookaheadLineReader struct {
 	position                       Position
 	readPositions, unreadPositions *container.RingBuffer
-	lineBuf                        *bytes.Buffer
+	index                          *lineIndex
 	reader                         Reader
 }
 
 func NewLookaheadLineReader(rd Reader, lookahead uint64) LineReader {
+	return newLookaheadLineReader(rd, lookahead, 1)
+}
+
+func newLookaheadLineReader(rd Reader, lookahead uint64, tabWidth int) *lookaheadLineReader {
+	_, source := readerSource(rd)
 	return &lookaheadLineReader{
 		reader:          rd,
 		position:        Position{Line: 1, Column: 1},
-		lineBuf:         new(bytes.Buffer),
+		index:           newLineIndex(tabWidth, int(lookahead), source),
 		readPositions:   container.NewRingBuffer(lookahead),
 		unreadPositions: container.NewRingBuffer(lookahead),
 	}
@@ -28,7 +31,11 @@ func (rd *lookaheadLineReader) Position() Position {
 }
 
 func (rd *lookaheadLineReader) Line() string {
-	return rd.lineBuf.String()
+	return rd.index.prefix(rd.position)
+}
+
+func (rd *lookaheadLineReader) SourceLine(line int) (string, bool) {
+	return rd.index.line(line)
 }
 
 func (rd *lookaheadLineReader) PeekRune() rune {
@@ -38,39 +45,30 @@ func (rd *lookaheadLineReader) PeekRune() rune {
 func (rd *lookaheadLineReader) ReadRune() rune {
 	pv, err := rd.unreadPositions.Pop()
 	if err == nil {
-		rd.readPositions.Push(pv)
-		r := rd.reader.ReadRune()
-		rd.lineBuf.WriteRune(r)
-		return r
+		rd.readPositions.Push(rd.position)
+		rd.position = pv.(Position)
+		return rd.reader.ReadRune()
 	}
 
-	rd.readPositions.Push(rd.position)
 	r := rd.reader.ReadRune()
-	switch {
-	case r == '\n':
-		rd.lineBuf.Reset()
-		rd.position.Column = 1
-		rd.position.Line++
-	case r > 0:
-		rd.lineBuf.WriteRune(r)
-		rd.position.Column++
+	if r < 0 {
+		return r
 	}
+
+	rd.readPositions.Push(rd.position)
+	rd.position = rd.index.advance(rd.position, r)
 	return r
 }
 
 func (rd *lookaheadLineReader) UnreadRune() {
-	if rd.lineBuf.Len() == 0 {
-		return
-	}
 	p, err := rd.readPositions.Pop()
 	if err != nil {
 		return
 	}
 
 	rd.reader.UnreadRune()
-	rd.lineBuf.Truncate(rd.lineBuf.Len() - 1)
-
-	rd.unreadPositions.Push(p)
+	rd.unreadPositions.Push(rd.position)
+	rd.position = p.(Position)
 }
 
 func (rd *lookaheadLineReader) Err() error {
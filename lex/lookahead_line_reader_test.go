@@ -1,6 +1,7 @@
 package lex
 
 import (
+	"io"
 	"strings"
 	"testing"
 )
@@ -56,3 +57,117 @@ func TestLookaheadLineReaderPeekRune(t *testing.T) {
 		t.Error("Expected runeEOF, got", r)
 	}
 }
+
+func TestLookaheadLineReaderUnreadAcrossNewline(t *testing.T) {
+	rd := NewLookaheadLineReader(NewLookaheadReader(strings.NewReader("ab\ncd"), 4), 4)
+	for i := 0; i < 4; i++ {
+		rd.ReadRune()
+	}
+	rd.UnreadRune()
+	rd.UnreadRune()
+
+	expectPosition(t, rd, Position{Line: 1, Column: 3, Offset: 2})
+	if line := rd.Line(); line != "ab" {
+		t.Error("Expected line so far to be \"ab\", got", line)
+	}
+
+	r := rd.ReadRune()
+	if r != '\n' {
+		t.Error("Expected '\\n', got", r)
+	}
+	expectPosition(t, rd, Position{Line: 2, Column: 1, Offset: 3})
+}
+
+func TestLineReadersReturnCompleteSourceLines(t *testing.T) {
+	input := "first line\r\nsecond\n\nlast"
+	readers := []LineReader{
+		NewLineReader(NewLookaheadReader(strings.NewReader(input), 4)),
+		NewLookaheadLineReader(NewLookaheadReader(strings.NewReader(input), 4), 4),
+	}
+
+	for _, rd := range readers {
+		for i := 0; i < len("first"); i++ {
+			rd.ReadRune()
+		}
+		if line := rd.Line(); line != "first" {
+			t.Error("Expected line so far to be \"first\", got", line)
+		}
+		if _, ok := rd.SourceLine(2); ok {
+			t.Error("Expected line 2 to be unavailable before it is read")
+		}
+
+		for rd.ReadRune() >= 0 {
+		}
+
+		expected := []string{"first line", "second", "", "last"}
+		for i, e := range expected {
+			line, ok := rd.SourceLine(i + 1)
+			if !ok || line != e {
+				t.Errorf("Expected line %d to be %q, got %q", i+1, e, line)
+			}
+		}
+		expectPosition(t, rd, Position{Line: 4, Column: 5, Offset: len(input)})
+	}
+}
+
+func expectPosition(t *testing.T, rd LineReader, expected Position) {
+	if p := rd.Position(); p != expected {
+		t.Errorf("Expected position %+v, got %+v", expected, p)
+	}
+}
+
+func TestLineReadersKeepEveryLine(t *testing.T) {
+	input := strings.Repeat("line\n", 100) + "last"
+	readers := []LineReader{
+		NewLineReader(NewLookaheadReader(struct{ io.RuneScanner }{strings.NewReader(input)}, 4)),
+		NewLookaheadLineReader(NewLookaheadReader(struct{ io.RuneScanner }{strings.NewReader(input)}, 4), 4),
+	}
+
+	for _, rd := range readers {
+		for rd.ReadRune() >= 0 {
+		}
+		for _, n := range []int{1, 50, 100} {
+			if line, ok := rd.SourceLine(n); !ok || line != "line" {
+				t.Error("Expected line", n, "to be kept without a source to read it back from, got", line)
+			}
+		}
+		if line, ok := rd.SourceLine(101); !ok || line != "last" {
+			t.Error("Expected line 101 to be kept, got", line)
+		}
+	}
+
+	ix := newLineIndex(1, 4, readerAtSource{strings.NewReader(input)})
+	p := Position{Line: 1, Column: 1}
+	for _, r := range input {
+		p = ix.advance(p, r)
+	}
+	if len(ix.text) > len("line\n")*5+len("last") {
+		t.Error("Expected the text of the last 5 lines at most to be kept with a source, got", len(ix.text), "bytes")
+	}
+	if line, ok := ix.line(1); !ok || line != "line" {
+		t.Error("Expected line 1 to be read back from the source, got", line)
+	}
+}
+
+func TestNewReadsForgottenLinesBackFromTheSource(t *testing.T) {
+	lines := strings.Repeat("line\n", 100)
+	cases := []struct {
+		input    string
+		encoding Encoding
+	}{
+		{lines + "l\u00E4st", UTF8},
+		{lines + "l\xE4st", Latin1},
+		{"\xEF\xBB\xBF" + lines + "l\u00E4st", UTF8},
+	}
+
+	for _, c := range cases {
+		lexer := New(strings.NewReader(c.input), WithEncoding(c.encoding))
+		lexer.Lex()
+		if line, ok := SourceLine(lexer, 1); !ok || line != "line" {
+			t.Error("Expected line 1 to be read back in", c.encoding, "got", line)
+		}
+		if line, ok := SourceLine(lexer, 101); !ok || line != "l\u00E4st" {
+			t.Error("Expected line 101 in", c.encoding, "got", line)
+		}
+	}
+}
@@ -9,19 +9,29 @@ import (
 type lookaheadReader struct {
 	reader
 	readRunes, unreadRunes *container.RingBuffer
+	lookahead              uint64
+	source                 textSource
 }
 
 func NewLookaheadReader(scanner io.RuneScanner, lookahead uint64) Reader {
+	return newLookaheadReaderWithSource(scanner, lookahead, sourceOf(scanner))
+}
+
+func newLookaheadReaderWithSource(scanner io.RuneScanner, lookahead uint64, source textSource) *lookaheadReader {
 	return &lookaheadReader{
 		reader:      reader{scanner: scanner},
 		readRunes:   container.NewRingBuffer(lookahead),
 		unreadRunes: container.NewRingBuffer(lookahead),
+		lookahead:   lookahead,
+		source:      source,
 	}
 }
 
 func (rd *lookaheadReader) PeekRune() rune {
 	r := rd.ReadRune()
-	rd.UnreadRune()
+	if r >= 0 {
+		rd.UnreadRune()
+	}
 	return r
 }
 
@@ -33,7 +43,7 @@ func (rd *lookaheadReader) ReadRune() rune {
 	}
 
 	r := rd.reader.ReadRune()
-	if r != runeError {
+	if r >= 0 {
 		rd.readRunes.Push(r)
 	}
 	return r
@@ -0,0 +1,128 @@
+package lex
+
+type Option func(*config)
+
+type config struct {
+	standard     Standard
+	errors       ErrorPolicy
+	fileName     string
+	tabWidth     int
+	trivia       bool
+	lookahead    uint64
+	maxErrors    int
+	interner     *Interner
+	normalize    bool
+	encoding     Encoding
+	codePage     *CodePage
+	rawBytes     bool
+	bidiCheck    BidiCheck
+	bidiSeverity Severity
+}
+
+// minLookahead is the fewest runes the lexer must be able to unread, which
+// it needs to back out of `%:%` when it is not followed by `:`.
+const minLookahead = 3
+
+func makeConfig(opts []Option) config {
+	cfg := config{
+		standard:     C11,
+		errors:       discardErrorPolicy{},
+		tabWidth:     1,
+		trivia:       true,
+		lookahead:    4,
+		bidiCheck:    BidiUnpaired,
+		bidiSeverity: SeverityWarning,
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// WithStandard selects the revision of C whose lexical grammar is used.
+// The default is C11.
+func WithStandard(s Standard) Option {
+	return func(cfg *config) { cfg.standard = s }
+}
+
+// WithErrorPolicy sets the policy lexical errors are reported to. By
+// default errors are discarded.
+func WithErrorPolicy(policy ErrorPolicy) Option {
+	return func(cfg *config) { cfg.errors = policy }
+}
+
+// WithFileName names the input in the diagnostics the lexer reports.
+func WithFileName(name string) Option {
+	return func(cfg *config) { cfg.fileName = name }
+}
+
+// WithTabWidth makes a tab advance the column to the next multiple of n
+// plus one, as GCC's -ftabstop does, rather than by a single column.
+func WithTabWidth(n int) Option {
+	return func(cfg *config) { cfg.tabWidth = n }
+}
+
+// WithTrivia sets whether Whitespace and Comment lexemes are returned.
+// They are by default.
+func WithTrivia(keep bool) Option {
+	return func(cfg *config) { cfg.trivia = keep }
+}
+
+// WithLookahead sets how many runes the reader chain built by New can
+// unread. Values below the lexer's minimum of 3 are raised to it.
+func WithLookahead(n uint64) Option {
+	return func(cfg *config) { cfg.lookahead = max(n, minLookahead) }
+}
+
+// WithMaxErrors stops lexing once n errors have been reported: the lexeme
+// containing the nth error is still returned, after which Next returns
+// ErrTooManyErrors. A limit of zero means no limit.
+func WithMaxErrors(n int) Option {
+	return func(cfg *config) { cfg.maxErrors = n }
+}
+
+// WithInterner interns the values of Identifier and Keyword lexemes in in,
+// which may be shared with other lexers. By default values aren't interned.
+func WithInterner(in *Interner) Option {
+	return func(cfg *config) { cfg.interner = in }
+}
+
+// WithNormalizedNames sets whether the interner of WithInterner interns the
+// NormalizedName of identifiers rather than their Name, so that spellings
+// that differ only in how characters are composed share a symbol. They
+// don't by default.
+func WithNormalizedNames(normalize bool) Option {
+	return func(cfg *config) { cfg.normalize = normalize }
+}
+
+// WithEncoding sets the encoding of the source read by New, LexString,
+// LexBytes and LexFiles. Sources that aren't UTF-8 are decoded first, so
+// lexeme values and offsets refer to the decoded text. A byte order mark
+// overrides the encoding. The default is UTF8.
+func WithEncoding(e Encoding) Option {
+	return func(cfg *config) { cfg.encoding, cfg.codePage = e, nil }
+}
+
+// WithCodePage decodes sources in a single-byte encoding other than the
+// ones with an Encoding.
+func WithCodePage(cp *CodePage) Option {
+	return func(cfg *config) { cfg.encoding, cfg.codePage = UTF8, cp }
+}
+
+// WithRawBytes keeps bytes that aren't valid UTF-8 as they are in lexeme
+// values, rather than replacing each with U+FFFD. Either way every such byte
+// is reported.
+func WithRawBytes(keep bool) Option {
+	return func(cfg *config) { cfg.rawBytes = keep }
+}
+
+// WithBidiCheck sets which bidirectional control characters in comments
+// and literals are reported, and with what severity. By default unpaired
+// ones are reported as warnings.
+func WithBidiCheck(check BidiCheck, severity Severity) Option {
+	return func(cfg *config) { cfg.bidiCheck, cfg.bidiSeverity = check, severity }
+}
+
+type discardErrorPolicy struct{}
+
+func (ep discardErrorPolicy) ReportError(message string, line string, position Position) {}
@@ -0,0 +1,67 @@
+package lex
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/denzel-morris/clex/lex/lexemes"
+)
+
+func TestNewDropsTrivia(t *testing.T) {
+	lexemelist, err := New(strings.NewReader("a /* b */ c\n"), WithTrivia(false)).Lex()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Lexeme{
+		{lexemes.Identifier, "a"},
+		{lexemes.Identifier, "c"},
+	}
+	if !equalLexemes(lexemelist, expected) {
+		t.Error("Expected", expected, "got", lexemelist)
+	}
+}
+
+func TestNewSelectsStandardKeywords(t *testing.T) {
+	for standard, expected := range map[Standard]lexemes.Type{
+		C11: lexemes.Identifier,
+		C23: lexemes.Keyword,
+	} {
+		for _, input := range []string{"bool", "nullptr", "typeof_unqual", "_BitInt"} {
+			lexeme, _ := New(strings.NewReader(input), WithStandard(standard)).Next()
+			if lexeme.Type != expected {
+				t.Error("Expected", expected, "for", input, "in", standard, "got", lexeme)
+			}
+		}
+
+		lexeme, _ := New(strings.NewReader("_Alignas"), WithStandard(standard)).Next()
+		if lexeme.Type != lexemes.Keyword {
+			t.Error("Expected _Alignas to be a keyword in", standard)
+		}
+	}
+}
+
+func TestNewReportsFileNameAndTabStops(t *testing.T) {
+	policy := &CollectingErrorPolicy{}
+	lexer := New(strings.NewReader("\tx\t$"),
+		WithErrorPolicy(policy),
+		WithFileName("test.c"),
+		WithTabWidth(8),
+	)
+	lexer.Lex()
+
+	diagnostics := policy.Diagnostics()
+	if len(diagnostics) != 1 {
+		t.Fatal("Expected 1 diagnostic, got", diagnostics)
+	}
+	if d := diagnostics[0]; d.File != "test.c" || d.Start.Column != 17 {
+		t.Errorf("Expected test.c at column 17, got %s at column %d", d.File, d.Start.Column)
+	}
+}
+
+func TestNewRaisesLookaheadToMinimum(t *testing.T) {
+	lexeme, _ := New(strings.NewReader("%:%"), WithLookahead(1)).Next()
+	if lexeme != (Lexeme{lexemes.Hash, "%:"}) {
+		t.Error("Expected Hash{%:}, got", lexeme)
+	}
+}
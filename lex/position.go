@@ -2,8 +2,12 @@ package lex
 
 import "fmt"
 
+// Position locates a rune in the source. Line and Column count from 1, with
+// columns counted in runes; Offset is the byte offset from the start of the
+// input.
 type Position struct {
 	Line, Column int
+	Offset       int
 }
 
 func (p Position) String() string {
@@ -47,5 +47,9 @@ func startsFloatingSuffix(r rune) bool {
 }
 
 func startsExponentPart(r rune) bool {
-	return r == 'e' || r == 'E' || r == 'p' || r == 'P'
+	return r == 'e' || r == 'E'
+}
+
+func startsBinaryExponentPart(r rune) bool {
+	return r == 'p' || r == 'P'
 }
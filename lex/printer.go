@@ -0,0 +1,15 @@
+package lex
+
+import "io"
+
+// Print writes the values of lexemelist to w. Every character of the input
+// belongs to exactly one lexeme, including whitespace, comments and invalid
+// input, so printing the lexemes of a source reproduces it exactly.
+func Print(w io.Writer, lexemelist []Lexeme) error {
+	for _, lexeme := range lexemelist {
+		if _, err := io.WriteString(w, lexeme.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
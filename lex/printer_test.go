@@ -0,0 +1,73 @@
+package lex
+
+import (
+	"bytes"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestPrintReproducesInput(t *testing.T) {
+	for _, input := range roundTripCorpus() {
+		for name, newLineReader := range lineReaderChains {
+			lexer := NewLexer(newLineReader(input), &EmptyErrorPolicy{})
+			lexemelist, err := lexer.Lex()
+			if err != nil {
+				t.Errorf("%s: got error %v on %q", name, err, input)
+				continue
+			}
+
+			var out bytes.Buffer
+			if err := Print(&out, lexemelist); err != nil {
+				t.Fatal(err)
+			}
+			if out.String() != input {
+				t.Errorf("%s: printed %q for %q via %v", name, out.String(), input, lexemelist)
+			}
+		}
+	}
+}
+
+var lineReaderChains = map[string]func(string) LineReader{
+	"lineReader": func(input string) LineReader {
+		return NewLineReader(NewLookaheadReader(strings.NewReader(input), 4))
+	},
+	"lookaheadLineReader": func(input string) LineReader {
+		return NewLookaheadLineReader(NewLookaheadReader(strings.NewReader(input), 4), 4)
+	},
+}
+
+var roundTripInputs = []string{
+	"",
+	"int main(int argc, char **argv) {\r\n\tputs(\"Hello, world!\");\r\n}\r\n",
+	"a+++++b",
+	"x=y/*z",
+	"x=y//z\r\nw",
+	`"\z abc" "unterminated` + "\n" + `'\q'`,
+	"\\u000 \\U0000000 \\b $ @ ` 0x 2E 1.0E+ .. ... %:%:%: <::><%%>",
+	"u8\"s\" u8 u8x L'a' U\"b\" u'c' L",
+	"0x1.p 1e 1.e+5f 0xAbp-1L 123ULL 0777 08 .5.",
+	"/* unterminated comment",
+	"trailing data without newline",
+	"é ​ ünïcödé\t\v\f",
+}
+
+const roundTripFragments = "a e E p P x X u U L 8 0 1 9 . + - * / % : < > = # ' \" \\ \n \r\n \t $ é ; { ( ["
+
+// roundTripCorpus returns the hand written inputs followed by random
+// concatenations of fragments that are prone to confusing the lexer.
+func roundTripCorpus() []string {
+	fragments := strings.Split(roundTripFragments, " ")
+	fragments = append(fragments, " ")
+
+	rnd := rand.New(rand.NewSource(1))
+	corpus := append([]string(nil), roundTripInputs...)
+	for i := 0; i < 2000; i++ {
+		var sb strings.Builder
+		for n := rnd.Intn(24); n > 0; n-- {
+			sb.WriteString(fragments[rnd.Intn(len(fragments))])
+		}
+		corpus = append(corpus, sb.String())
+	}
+	return corpus
+}
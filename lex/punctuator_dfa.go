@@ -0,0 +1,53 @@
+package lex
+
+import (
+	"unicode/utf8"
+
+	"github.com/denzel-morris/clex/lex/lexemes"
+)
+
+// punctuatorDFA is a trie over punctuatorToType. Every punctuator is ASCII,
+// so transitions are indexed by byte. State 0 is the root, which no
+// transition re-enters, so a zero transition means there is none.
+type punctuatorDFA []punctuatorState
+
+type punctuatorState struct {
+	typ  lexemes.Type // lexemes.Invalid for prefixes that aren't punctuators
+	next [utf8.RuneSelf]uint8
+}
+
+var punctuators = buildPunctuatorDFA(punctuatorToType)
+
+func buildPunctuatorDFA(punctuators map[string]lexemes.Type) punctuatorDFA {
+	dfa := make(punctuatorDFA, 1)
+	for punctuator, typ := range punctuators {
+		state := 0
+		for i := 0; i < len(punctuator); i++ {
+			c := punctuator[i]
+			if dfa[state].next[c] == 0 {
+				dfa = append(dfa, punctuatorState{})
+				dfa[state].next[c] = uint8(len(dfa) - 1)
+			}
+			state = int(dfa[state].next[c])
+		}
+		dfa[state].typ = typ
+	}
+	return dfa
+}
+
+func (dfa punctuatorDFA) step(state int, r rune) (int, bool) {
+	if r < 0 || r >= utf8.RuneSelf {
+		return 0, false
+	}
+	next := dfa[state].next[r]
+	return int(next), next != 0
+}
+
+// walk returns the state reached on s, which must be a punctuator prefix.
+func (dfa punctuatorDFA) walk(s string) int {
+	state := 0
+	for i := 0; i < len(s); i++ {
+		state, _ = dfa.step(state, rune(s[i]))
+	}
+	return state
+}
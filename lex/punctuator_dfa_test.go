@@ -0,0 +1,93 @@
+package lex
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/denzel-morris/clex/lex/lexemes"
+)
+
+// munchDFA returns the longest punctuator at the start of src.
+func munchDFA(src string) (lexemes.Type, int) {
+	state, typ, accepted := 0, lexemes.Invalid, 0
+	for i := 0; i < len(src); i++ {
+		next, ok := punctuators.step(state, rune(src[i]))
+		if !ok {
+			break
+		}
+		if state = next; punctuators[state].typ != lexemes.Invalid {
+			typ, accepted = punctuators[state].typ, i+1
+		}
+	}
+	return typ, accepted
+}
+
+// munchMap is the map-probing approach the DFA replaced: extend the value
+// while it stays a prefix in punctuatorToType, then back out to the longest
+// valid punctuator.
+func munchMap(src string) (lexemes.Type, int) {
+	n := 0
+	for n < len(src) {
+		if _, present := punctuatorToType[src[:n+1]]; !present {
+			break
+		}
+		n++
+	}
+	for n > 0 && punctuatorToType[src[:n]] == lexemes.Invalid {
+		n--
+	}
+	return punctuatorToType[src[:n]], n
+}
+
+func TestPunctuatorDFAMatchesMap(t *testing.T) {
+	alphabet := "[](){}.+-*/!<>?:;=%^~&|#,x"
+	var check func(prefix string)
+	check = func(prefix string) {
+		dfaType, dfaLength := munchDFA(prefix)
+		mapType, mapLength := munchMap(prefix)
+		if dfaType != mapType || dfaLength != mapLength {
+			t.Error("Expected", mapType, mapLength, "for", prefix, "got", dfaType, dfaLength)
+		}
+		if len(prefix) == 4 {
+			return
+		}
+		for _, r := range alphabet {
+			check(prefix + string(r))
+		}
+	}
+	check("")
+}
+
+func TestLexPunctuatorBacksOut(t *testing.T) {
+	cases := []struct {
+		input    string
+		expected []Lexeme
+	}{
+		{"%:%x", []Lexeme{{lexemes.Hash, "%:"}, {lexemes.Percent, "%"}, {lexemes.Identifier, "x"}}},
+		{"..x", []Lexeme{{lexemes.Period, "."}, {lexemes.Period, "."}, {lexemes.Identifier, "x"}}},
+		{"%:%", []Lexeme{{lexemes.Hash, "%:"}, {lexemes.Percent, "%"}}},
+		{"<<==", []Lexeme{{lexemes.DoubleLessThanEqual, "<<="}, {lexemes.Equal, "="}}},
+		{"/=.", []Lexeme{{lexemes.ForwardSlashEqual, "/="}, {lexemes.Period, "."}}},
+	}
+	for _, c := range cases {
+		actual, err := LexString(c.input)
+		if err != nil || !equalLexemes(actual, c.expected) {
+			t.Error("Expected", c.expected, "got", actual, err)
+		}
+	}
+}
+
+func benchmarkMunch(b *testing.B, munch func(string) (lexemes.Type, int)) {
+	src := strings.ReplaceAll(punctuationSource(1000), "\n", "")
+	b.SetBytes(int64(len(src)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for rest := src; rest != ""; {
+			_, n := munch(rest)
+			rest = rest[max(n, 1):]
+		}
+	}
+}
+
+func BenchmarkMunchPunctuatorDFA(b *testing.B) { benchmarkMunch(b, munchDFA) }
+func BenchmarkMunchPunctuatorMap(b *testing.B) { benchmarkMunch(b, munchMap) }
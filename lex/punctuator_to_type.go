@@ -47,6 +47,7 @@ var punctuatorToType = map[string]lexemes.Type{
 	"%=":   lexemes.PercentEqual,
 	"&=":   lexemes.AmpersandEqual,
 	"|=":   lexemes.PipeEqual,
+	"^=":   lexemes.CaretEqual,
 	"##":   lexemes.DoubleHash,
 	"<:":   lexemes.LeftBracket,
 	":>":   lexemes.RightBracket,
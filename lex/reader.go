@@ -1,6 +1,9 @@
 package lex
 
-import "io"
+import (
+	"io"
+	"unicode/utf8"
+)
 
 type Reader interface {
 	PeekRune() rune
@@ -12,6 +15,7 @@ type Reader interface {
 type reader struct {
 	scanner io.RuneScanner
 	err     error
+	escaped bool // whether the last rune read was an escaped byte
 }
 
 const (
@@ -35,7 +39,7 @@ func (rd *reader) ReadRune() rune {
 		return runeError
 	}
 
-	r, _, err := rd.scanner.ReadRune()
+	r, size, err := rd.scanner.ReadRune()
 
 	switch {
 	case err == io.EOF:
@@ -45,10 +49,32 @@ func (rd *reader) ReadRune() rune {
 		return runeError
 	}
 
+	rd.escaped = r == utf8.RuneError && size == 1
+	if rd.escaped {
+		return rd.escapeByte()
+	}
 	return r
 }
 
+// escapeByte rereads the byte the scanner failed to decode so that it can
+// be returned as an escaped byte. Scanners that can't unread bytes leave it
+// as U+FFFD.
+func (rd *reader) escapeByte() rune {
+	bs, ok := rd.scanner.(io.ByteScanner)
+	if !ok || bs.UnreadByte() != nil {
+		rd.escaped = false
+		return utf8.RuneError
+	}
+	b, _ := bs.ReadByte()
+	return escapeByte(b)
+}
+
 func (rd *reader) UnreadRune() {
+	if rd.escaped {
+		rd.escaped = false
+		rd.scanner.(io.ByteScanner).UnreadByte()
+		return
+	}
 	rd.scanner.UnreadRune()
 }
 
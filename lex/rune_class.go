@@ -2,7 +2,6 @@ package lex
 
 import (
 	"strings"
-	"unicode"
 )
 
 type runeClass interface {
@@ -56,14 +55,21 @@ func (rc oneRune) has(r rune) bool {
 }
 
 var (
-	any            runeClassFunc = isAny
-	decimalDigit   runeClassFunc = isDecimalDigit
-	hexDigit       runeClassFunc = isHexDigit
-	octalDigit     runeClassFunc = isOctalDigit
-	whitespace     runeClassFunc = isWhitespace
-	decimalPoint   runeClassFunc = isDecimalPoint
-	simpleEscape   runeClassFunc = isSimpleEscape
-	identifierChar runeClassFunc = isIdentifierChar
+	any          runeClassFunc = isAny
+	decimalDigit runeClassFunc = isDecimalDigit
+	hexDigit     runeClassFunc = isHexDigit
+	octalDigit   runeClassFunc = isOctalDigit
+	whitespace   runeClassFunc = isWhitespace
+	decimalPoint runeClassFunc = isDecimalPoint
+	simpleEscape runeClassFunc = isSimpleEscape
+	wordChar     runeClassFunc = isWordChar
+	undecodable  runeClassFunc = isUndecodable
+
+	// Complements used on hot paths are built once so that lexing a string,
+	// character or comment doesn't box a new runeClass each time.
+	notNewline          = complement(oneRune('\n'))
+	notStringLiteralEnd = complement(oneOf("\"\n"))
+	notCharLiteralEnd   = complement(oneOf("'\n"))
 )
 
 func isAny(r rune) bool { return true }
@@ -97,7 +103,7 @@ func isOctalDigit(r rune) bool {
 
 func isNonDigit(r rune) bool {
 	switch {
-	case r == '_' || unicode.IsLetter(r):
+	case r == '_', r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
 		return true
 	default:
 		return false
@@ -132,3 +138,12 @@ func isIdentifierChar(r rune) bool {
 		return false
 	}
 }
+
+func isWordChar(r rune) bool {
+	switch {
+	case isIdentifierChar(r), isDecimalPoint(r), r >= 0x80:
+		return true
+	default:
+		return false
+	}
+}
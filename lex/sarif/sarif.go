@@ -0,0 +1,202 @@
+// Package sarif converts lexical diagnostics into SARIF 2.1.0 logs, the
+// interchange format understood by code review and static analysis
+// dashboards.
+package sarif
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/denzel-morris/clex/lex"
+)
+
+const (
+	Version = "2.1.0"
+	Schema  = "https://json.schemastore.org/sarif-2.1.0.json"
+)
+
+type Log struct {
+	Version string `json:"version"`
+	Schema  string `json:"$schema"`
+	Runs    []Run  `json:"runs"`
+
+	ruleIndexes map[lex.Code]int
+}
+
+type Run struct {
+	Tool       Tool     `json:"tool"`
+	ColumnKind string   `json:"columnKind"`
+	Results    []Result `json:"results"`
+}
+
+type Tool struct {
+	Driver Driver `json:"driver"`
+}
+
+type Driver struct {
+	Name           string                `json:"name"`
+	InformationURI string                `json:"informationUri"`
+	Rules          []ReportingDescriptor `json:"rules"`
+}
+
+type ReportingDescriptor struct {
+	ID                   string        `json:"id"`
+	Name                 string        `json:"name"`
+	ShortDescription     Message       `json:"shortDescription"`
+	DefaultConfiguration Configuration `json:"defaultConfiguration"`
+}
+
+type Configuration struct {
+	Level string `json:"level"`
+}
+
+type Message struct {
+	Text string `json:"text"`
+}
+
+type Result struct {
+	RuleID    string     `json:"ruleId"`
+	RuleIndex int        `json:"ruleIndex"`
+	Level     string     `json:"level"`
+	Message   Message    `json:"message"`
+	Locations []Location `json:"locations"`
+	Fixes     []Fix      `json:"fixes,omitempty"`
+}
+
+type Location struct {
+	PhysicalLocation PhysicalLocation `json:"physicalLocation"`
+}
+
+type PhysicalLocation struct {
+	ArtifactLocation ArtifactLocation `json:"artifactLocation"`
+	Region           Region           `json:"region"`
+}
+
+type ArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type Region struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn"`
+	EndLine     int `json:"endLine"`
+	EndColumn   int `json:"endColumn"`
+}
+
+type Fix struct {
+	Description     Message          `json:"description"`
+	ArtifactChanges []ArtifactChange `json:"artifactChanges"`
+}
+
+type ArtifactChange struct {
+	ArtifactLocation ArtifactLocation `json:"artifactLocation"`
+	Replacements     []Replacement    `json:"replacements"`
+}
+
+type Replacement struct {
+	DeletedRegion   Region   `json:"deletedRegion"`
+	InsertedContent *Content `json:"insertedContent,omitempty"`
+}
+
+type Content struct {
+	Text string `json:"text"`
+}
+
+var severityToLevel = map[lex.Severity]string{
+	lex.SeverityError:   "error",
+	lex.SeverityWarning: "warning",
+	lex.SeverityNote:    "note",
+}
+
+// NewLog returns a log with a single run describing clex and every rule it
+// can report. Rules are indexed in the order of lex.Codes.
+func NewLog() *Log {
+	var rules []ReportingDescriptor
+	ruleIndexes := map[lex.Code]int{}
+	for i, code := range lex.Codes() {
+		ruleIndexes[code] = i
+		rules = append(rules, ReportingDescriptor{
+			ID:                   code.ID(),
+			Name:                 code.String(),
+			ShortDescription:     Message{code.Description()},
+			DefaultConfiguration: Configuration{severityToLevel[code.DefaultSeverity()]},
+		})
+	}
+
+	return &Log{
+		Version: Version,
+		Schema:  Schema,
+		Runs: []Run{{
+			Tool: Tool{Driver{
+				Name:           "clex",
+				InformationURI: "https://github.com/denzel-morris/clex",
+				Rules:          rules,
+			}},
+			ColumnKind: "unicodeCodePoints",
+			Results:    []Result{},
+		}},
+		ruleIndexes: ruleIndexes,
+	}
+}
+
+// Add records the diagnostics reported for the artifact at uri. The log
+// declares columns in Unicode code points, so the diagnostics must come from
+// a lexer counting one column per rune, as it does unless built WithTabWidth.
+func (l *Log) Add(uri string, diagnostics ...lex.Diagnostic) {
+	run := &l.Runs[0]
+	for _, d := range diagnostics {
+		run.Results = append(run.Results, l.makeResult(uri, d))
+	}
+}
+
+func (l *Log) Encode(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(l)
+}
+
+func (l *Log) makeResult(uri string, d lex.Diagnostic) Result {
+	artifact := ArtifactLocation{URI: uri}
+	result := Result{
+		RuleID:    d.Code.ID(),
+		RuleIndex: l.ruleIndexes[d.Code],
+		Level:     severityToLevel[d.Severity],
+		Message:   Message{d.Message},
+		Locations: []Location{{PhysicalLocation{artifact, diagnosticRegion(d)}}},
+	}
+
+	for _, fix := range d.Fixes {
+		replacement := Replacement{DeletedRegion: makeRegion(fix.Start, fix.End)}
+		if fix.Text != "" {
+			replacement.InsertedContent = &Content{fix.Text}
+		}
+		result.Fixes = append(result.Fixes, Fix{
+			Description: Message{fix.Description},
+			ArtifactChanges: []ArtifactChange{{
+				ArtifactLocation: artifact,
+				Replacements:     []Replacement{replacement},
+			}},
+		})
+	}
+	return result
+}
+
+// diagnosticRegion spans the consumed part of the offending lexeme. When
+// nothing was consumed the region covers the character at the position.
+func diagnosticRegion(d lex.Diagnostic) Region {
+	region := makeRegion(d.Start, d.Position)
+	if d.Start == d.Position || d.Start.Line == 0 {
+		region = makeRegion(d.Position, d.Position)
+		region.EndColumn++
+	}
+	return region
+}
+
+func makeRegion(start, end lex.Position) Region {
+	return Region{
+		StartLine:   start.Line,
+		StartColumn: start.Column,
+		EndLine:     end.Line,
+		EndColumn:   end.Column,
+	}
+}
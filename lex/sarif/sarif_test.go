@@ -0,0 +1,77 @@
+package sarif
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/denzel-morris/clex/lex"
+)
+
+func TestLogDescribesEveryRule(t *testing.T) {
+	log := NewLog()
+	rules := log.Runs[0].Tool.Driver.Rules
+	if len(rules) != len(lex.Codes()) {
+		t.Fatal("Expected", len(lex.Codes()), "rules, got", len(rules))
+	}
+
+	seen := map[string]bool{}
+	for _, rule := range rules {
+		if seen[rule.ID] {
+			t.Error("Duplicate rule ID", rule.ID)
+		}
+		seen[rule.ID] = true
+	}
+
+	levels := map[string]string{"CLEX0002": "error", "CLEX0014": "warning", "CLEX0015": "warning", "CLEX0016": "warning", "CLEX0017": "warning"}
+	for _, rule := range rules {
+		if expected, ok := levels[rule.ID]; ok && rule.DefaultConfiguration.Level != expected {
+			t.Error("Expected", rule.ID, "to default to", expected, "got", rule.DefaultConfiguration.Level)
+		}
+	}
+}
+
+func TestLogRecordsLexicalErrors(t *testing.T) {
+	policy := &lex.CollectingErrorPolicy{}
+	lexer := lex.NewLexer(
+		lex.NewLookaheadLineReader(lex.NewLookaheadReader(strings.NewReader(`"\z"`), 4), 4),
+		policy,
+	)
+	lexer.Lex()
+
+	log := NewLog()
+	log.Add("src/test.c", policy.Diagnostics()...)
+
+	var out bytes.Buffer
+	if err := log.Encode(&out); err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded Log
+	if err := json.Unmarshal(out.Bytes(), &decoded); err != nil {
+		t.Fatal("Log is not valid JSON:", err)
+	}
+
+	results := decoded.Runs[0].Results
+	if len(results) == 0 {
+		t.Fatal("Expected results, got none")
+	}
+
+	result := results[0]
+	if result.RuleID != lex.CodeUnknownEscape.ID() {
+		t.Error("Expected rule", lex.CodeUnknownEscape.ID(), "got", result.RuleID)
+	}
+	if decoded.Runs[0].Tool.Driver.Rules[result.RuleIndex].ID != result.RuleID {
+		t.Error("Rule index", result.RuleIndex, "does not refer to", result.RuleID)
+	}
+	if uri := result.Locations[0].PhysicalLocation.ArtifactLocation.URI; uri != "src/test.c" {
+		t.Error("Expected uri src/test.c, got", uri)
+	}
+	if region := result.Locations[0].PhysicalLocation.Region; region.StartLine != 1 || region.StartColumn != 1 {
+		t.Error("Expected region to start at 1:1, got", region)
+	}
+	if len(result.Fixes) != 1 || result.Fixes[0].ArtifactChanges[0].Replacements[0].InsertedContent.Text != `\` {
+		t.Error("Expected a fix inserting a backslash, got", result.Fixes)
+	}
+}
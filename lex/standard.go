@@ -0,0 +1,17 @@
+package lex
+
+type Standard int
+
+const (
+	C11 Standard = iota
+	C23
+)
+
+var standardToName = map[Standard]string{
+	C11: "C11",
+	C23: "C23",
+}
+
+func (s Standard) String() string {
+	return standardToName[s]
+}
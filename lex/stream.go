@@ -0,0 +1,101 @@
+package lex
+
+import "context"
+
+const (
+	// cancellationInterval is how many lexemes LexContext produces between
+	// checks of its context.
+	cancellationInterval = 256
+
+	// streamBatchSize is the largest number of lexemes delivered in a single
+	// Result by Stream.
+	streamBatchSize = 256
+)
+
+// Result is a batch of lexemes delivered by Stream. The final Result before
+// the channel is closed carries the error that stopped the lexer, if any.
+type Result struct {
+	Lexemes []Lexeme
+	Err     error
+}
+
+// LexContext is like l.Lex but stops early, returning ctx.Err(), once ctx is
+// done. The context is checked between lexemes, so a read blocked in the
+// underlying reader is not interrupted.
+func LexContext(ctx context.Context, l Lexer) ([]Lexeme, error) {
+	return lexContext(ctx, l, nil)
+}
+
+// lexContext is LexContext calling each, unless it is nil, after every
+// lexeme it keeps.
+func lexContext(ctx context.Context, l Lexer, each func()) ([]Lexeme, error) {
+	var lexemelist []Lexeme
+	for lexeme, err := range l.All() {
+		if err != nil {
+			return lexemelist, err
+		}
+		lexemelist = append(lexemelist, lexeme)
+		if each != nil {
+			each()
+		}
+
+		if len(lexemelist)%cancellationInterval == 0 && ctx.Err() != nil {
+			return lexemelist, ctx.Err()
+		}
+	}
+	return lexemelist, nil
+}
+
+// Stream lexes l in a new goroutine, delivering lexemes in batches. The
+// channel holds a single batch, so the lexer runs at most one batch ahead of
+// the consumer. Once ctx is done the lexer stops and the channel is closed
+// after a final Result carrying ctx.Err(), which takes over the lexemes of a
+// batch still waiting in the channel.
+func Stream(ctx context.Context, l Lexer) <-chan Result {
+	results := make(chan Result, 1)
+	go func() {
+		defer close(results)
+
+		batch := make([]Lexeme, 0, streamBatchSize)
+		for lexeme, err := range l.All() {
+			if err != nil {
+				deliver(ctx, results, Result{batch, err})
+				return
+			}
+
+			batch = append(batch, lexeme)
+			if len(batch) == streamBatchSize {
+				if !deliver(ctx, results, Result{Lexemes: batch}) {
+					return
+				}
+				batch = make([]Lexeme, 0, streamBatchSize)
+			}
+		}
+
+		if len(batch) > 0 {
+			deliver(ctx, results, Result{Lexemes: batch})
+		}
+	}()
+	return results
+}
+
+func deliver(ctx context.Context, results chan Result, r Result) bool {
+	if ctx.Err() == nil {
+		select {
+		case results <- r:
+			return true
+		case <-ctx.Done():
+		}
+	}
+
+	// Only this goroutine sends, so once the channel is drained there is
+	// room for the error.
+	final := Result{Err: ctx.Err()}
+	select {
+	case waiting := <-results:
+		final.Lexemes = waiting.Lexemes
+	default:
+	}
+	results <- final
+	return false
+}
@@ -0,0 +1,105 @@
+package lex
+
+import (
+	"context"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestStreamDeliversEveryLexeme(t *testing.T) {
+	input := strings.Repeat("int x = 1; /* c */\n", 200)
+	expected, _ := makeLookaheadLexer(input, &EmptyErrorPolicy{}).Lex()
+
+	var streamed []Lexeme
+	batches := 0
+	for result := range Stream(context.Background(), makeLookaheadLexer(input, &EmptyErrorPolicy{})) {
+		if result.Err != nil {
+			t.Fatal(result.Err)
+		}
+		if len(result.Lexemes) > streamBatchSize {
+			t.Error("Expected batches of at most", streamBatchSize, "got", len(result.Lexemes))
+		}
+		streamed = append(streamed, result.Lexemes...)
+		batches++
+	}
+
+	if !equalLexemes(streamed, expected) {
+		t.Error("Streamed lexemes differ from Lex")
+	}
+	if batches < 2 {
+		t.Error("Expected the lexemes to arrive in several batches, got", batches)
+	}
+}
+
+func TestStreamDeliversLexerError(t *testing.T) {
+	var err error
+	for result := range Stream(context.Background(), makeLookaheadLexer("a $", &FailFastErrorPolicy{})) {
+		err = result.Err
+	}
+	if err == nil {
+		t.Error("Expected the final result to carry the lexer's error")
+	}
+}
+
+func TestStreamStopsWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	results := Stream(ctx, makeLookaheadLexer(strings.Repeat("a ", 100000), &EmptyErrorPolicy{}))
+
+	<-results
+	cancel()
+
+	var err error
+	received := 0
+	for result := range results {
+		err = result.Err
+		received++
+	}
+	if err != context.Canceled {
+		t.Error("Expected context.Canceled, got", err)
+	}
+	if received > 2 {
+		t.Error("Expected the lexer to stop promptly, got", received, "more results")
+	}
+}
+
+func TestLexContextStopsWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	lexemelist, err := LexContext(ctx, makeLookaheadLexer(strings.Repeat("a ", 100000), &EmptyErrorPolicy{}))
+	if err != context.Canceled {
+		t.Error("Expected context.Canceled, got", err)
+	}
+	if len(lexemelist) > cancellationInterval {
+		t.Error("Expected lexing to stop within", cancellationInterval, "lexemes, got", len(lexemelist))
+	}
+}
+
+func TestStreamDeliversCancellationWhenChannelIsFull(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	results := Stream(ctx, makeLookaheadLexer(strings.Repeat("a ", 100000), &EmptyErrorPolicy{}))
+
+	for len(results) < cap(results) {
+		runtime.Gosched()
+	}
+	cancel()
+
+	var last Result
+	for result := range results {
+		last = result
+	}
+	if last.Err != context.Canceled {
+		t.Error("Expected the final result to carry context.Canceled, got", last.Err)
+	}
+}
+
+func TestLexContextSucceedsOnceFinished(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	lexemelist, err := LexContext(ctx, makeLookaheadLexer("a b", &EmptyErrorPolicy{}))
+	if err != nil || len(lexemelist) != 3 {
+		t.Error("Expected 3 lexemes and no error once the input is lexed, got", lexemelist, err)
+	}
+}
@@ -0,0 +1,205 @@
+package lex
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// stringReader is a LineReader that decodes runes directly from a string,
+// with no buffering and no bound on how many runes can be unread.
+type stringReader struct {
+	src      string
+	position Position
+	tabWidth int
+
+	// lineOffset is the offset at which the line of position starts.
+	lineOffset int
+
+	// surrogates holds the unpaired surrogates the source was decoded
+	// from, by the offset of the U+FFFD that replaced them.
+	surrogates map[int]rune
+
+	// starts holds the offsets of the lines SourceLine has looked up so far.
+	starts []int
+}
+
+func newStringReader(src string, tabWidth int) *stringReader {
+	return &stringReader{
+		src:      src,
+		position: Position{Line: 1, Column: 1},
+		tabWidth: tabWidth,
+	}
+}
+
+func (rd *stringReader) PeekRune() rune {
+	if rd.position.Offset >= len(rd.src) {
+		return runeEOF
+	}
+	r, size := utf8.DecodeRuneInString(rd.src[rd.position.Offset:])
+	return rd.escape(r, size)
+}
+
+func (rd *stringReader) ReadRune() rune {
+	if rd.position.Offset >= len(rd.src) {
+		return runeEOF
+	}
+
+	r, size := utf8.DecodeRuneInString(rd.src[rd.position.Offset:])
+	r = rd.escape(r, size)
+	rd.position.Offset += size
+	switch r {
+	case '\n':
+		rd.position.Column = 1
+		rd.position.Line++
+		rd.lineOffset = rd.position.Offset
+	default:
+		rd.position.Column = nextColumn(rd.position.Column, r, rd.tabWidth)
+	}
+	return r
+}
+
+func (rd *stringReader) UnreadRune() {
+	if rd.position.Offset == 0 {
+		return
+	}
+
+	r, size := utf8.DecodeLastRuneInString(rd.src[:rd.position.Offset])
+	rd.position.Offset -= size
+	switch {
+	case r == '\n':
+		rd.position.Line--
+		rd.lineOffset = strings.LastIndexByte(rd.src[:rd.position.Offset], '\n') + 1
+		rd.position.Column = rd.column()
+	case r == '\t' && rd.tabWidth > 1:
+		rd.position.Column = rd.column()
+	default:
+		rd.position.Column--
+	}
+}
+
+// column computes the column of the current offset from the start of its
+// line, which only the end of a line or a tab stop requires.
+func (rd *stringReader) column() int {
+	column := 1
+	for _, r := range rd.src[rd.lineStart():rd.position.Offset] {
+		column = nextColumn(column, r, rd.tabWidth)
+	}
+	return column
+}
+
+// escape turns the rune decoded at the current offset into an escaped byte
+// if it is not valid UTF-8, or back into the unpaired surrogate it replaced.
+func (rd *stringReader) escape(r rune, size int) rune {
+	switch {
+	case r != utf8.RuneError:
+		return r
+	case size == 1:
+		return escapeByte(rd.src[rd.position.Offset])
+	}
+	if unit, ok := rd.surrogates[rd.position.Offset]; ok {
+		return escapeSurrogate(unit)
+	}
+	return r
+}
+
+func (rd *stringReader) Err() error {
+	return nil
+}
+
+func (rd *stringReader) Position() Position {
+	return rd.position
+}
+
+func (rd *stringReader) Line() string {
+	return rd.src[rd.lineStart():rd.position.Offset]
+}
+
+func (rd *stringReader) SourceLine(line int) (string, bool) {
+	if line < 1 {
+		return "", false
+	}
+	if rd.starts == nil {
+		rd.starts = []int{0}
+	}
+	for len(rd.starts) < line {
+		last := rd.starts[len(rd.starts)-1]
+		i := strings.IndexByte(rd.src[last:], '\n')
+		if i < 0 {
+			return "", false
+		}
+		rd.starts = append(rd.starts, last+i+1)
+	}
+
+	rest := rd.src[rd.starts[line-1]:]
+	if i := strings.IndexByte(rest, '\n'); i >= 0 {
+		rest = rest[:i]
+	}
+	return strings.TrimSuffix(rest, "\r"), true
+}
+
+func (rd *stringReader) lineStart() int {
+	return rd.lineOffset
+}
+
+// windowBuffer is the lexeme buffer used over a stringReader. Since a lexeme
+// is usually a contiguous run of the source, the buffer only tracks where the
+// lexeme starts and ends, and its value is a substring of the source. Only a
+// lexeme in which an invalid byte is replaced with U+FFFD is copied.
+type windowBuffer struct {
+	stream     *stringReader
+	start, end int
+	spilled    bool
+	spill      []byte
+}
+
+func (b *windowBuffer) WriteRune(r rune) (int, error) {
+	n := b.stream.position.Offset - b.end
+	b.end = b.stream.position.Offset
+	if r == utf8.RuneError && n == 1 && !b.spilled {
+		b.spill = append(b.spill[:0], b.stream.src[b.start:b.end-1]...)
+		b.spilled = true
+	}
+	if b.spilled {
+		b.spill = utf8.AppendRune(b.spill, r)
+	}
+	return n, nil
+}
+
+func (b *windowBuffer) WriteByte(c byte) error {
+	b.end = b.stream.position.Offset
+	if b.spilled {
+		b.spill = append(b.spill, c)
+	}
+	return nil
+}
+
+// Truncate drops all but the first n bytes of the lexeme. Once it has been
+// copied, the bytes dropped must have been read as single bytes, as ASCII
+// characters are.
+func (b *windowBuffer) Truncate(n int) {
+	if b.spilled {
+		b.end -= len(b.spill) - n
+		b.spill = b.spill[:n]
+		return
+	}
+	b.end = b.start + n
+}
+
+func (b *windowBuffer) Len() int {
+	if b.spilled {
+		return len(b.spill)
+	}
+	return b.end - b.start
+}
+
+func (b *windowBuffer) Reset() {
+	b.start = b.end
+	b.spilled = false
+}
+
+func (b *windowBuffer) String() string {
+	if b.spilled {
+		return string(b.spill)
+	}
+	return b.stream.src[b.start:b.end]
+}
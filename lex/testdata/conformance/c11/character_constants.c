@@ -0,0 +1,4 @@
+/* C11 6.4.4.4 Character constants */
+'a' '0' ' ' '"' '\'' '\"' '\?' '\\' '\a' '\b' '\f' '\n' '\r' '\t' '\v'
+'\0' '\7' '\77' '\101' '\x41' '\x7f' 'é' '\U0001F600'
+'ab' L'a' u'a' U'a' L'\0'
@@ -0,0 +1,10 @@
+// C11 6.4.9 Comments
+/* block */ /**/ /***/ /* * / */
+/* multi
+   line
+   comment */
+x // trailing comment
+y /* block */ z
+"/* in a string */" '/'
+a/**/b
+// comment ending the file without a newline
\ No newline at end of file
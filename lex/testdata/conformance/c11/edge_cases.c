@@ -0,0 +1,13 @@
+/* Maximal munch and other edge cases */
+a+++++b
+x=y/*z*/;
+x=y//z
+;
+a...b .. ... .... ..."..."
+%:%:%: %:% <::> <%%>
+a-->b a->b a- ->b
+x=-1 x-=1 x=--y
+1..2 1.e 1.e+ 0x1.p
+i+=+i i=+=i
+&&& ||| ===
+u8"x"u8'x' L'x'L"x"
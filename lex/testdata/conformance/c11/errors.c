@@ -0,0 +1,9 @@
+/* Lexical errors and recovery */
+int $dollar = @at + `backtick;
+0x 0xg 1e+ 1e- 0x1p 0x1.8 0x.p1
+'\q' "\z" '\xg' "\u00"
+\u000 \U0000000
+"unterminated string
+'unterminated char
+int after;
+/* unterminated comment
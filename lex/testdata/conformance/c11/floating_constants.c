@@ -0,0 +1,4 @@
+/* C11 6.4.4.2 Floating constants */
+0.0 1. .5 3.14159 1e10 1E10 1e+10 1e-10 1.5e3 .5e-3 6.02E+23
+1.0f 1.0F 1.0l 1.0L 1e3f .5L
+0x1p0 0x1P0 0x1.p1 0x.8p1 0xA.Bp-3 0x1p+4f 0x1.fffffep127L
@@ -0,0 +1,6 @@
+/* C11 6.4.2 Identifiers and 6.4.3 Universal character names */
+x _ _x x_ __func__ __LINE__ abc123 a1b2c3 _0 ABCdef
+Àngström \U0001F600face café
+\u00C0ngstr\u00F6m caf\u00E9 x\u03A9
+Ωmega naïve 变量
+long_identifier_name_that_goes_on_for_quite_a_while_to_check_nothing_is_truncated
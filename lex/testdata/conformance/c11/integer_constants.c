@@ -0,0 +1,6 @@
+/* C11 6.4.4.1 Integer constants */
+0 1 42 2147483647 9223372036854775807
+0777 017 00
+0x0 0xff 0XFF 0xDeadBeef
+1u 1U 1l 1L 1ll 1LL 1ul 1uL 1Ul 1UL 1lu 1LU 1ull 1ULL 1llu 1LLU
+0x10u 017L 0xffffffffffffffffULL
@@ -0,0 +1,9 @@
+/* C11 6.4.1 Keywords */
+auto break case char const continue default do double else enum extern
+float for goto if inline int long register restrict return short signed
+sizeof static struct switch typedef union unsigned void volatile while
+_Alignas _Alignof _Atomic _Bool _Complex _Generic _Imaginary _Noreturn
+_Static_assert _Thread_local
+
+/* Not keywords in C11 */
+bool true false nullptr typeof alignas _BitInt Int WHILE while_ _while
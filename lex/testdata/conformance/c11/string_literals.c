@@ -0,0 +1,5 @@
+/* C11 6.4.5 String literals */
+"" "a" "hello, world" "tab\there" "quote\"inside" "back\\slash"
+"\x41\102é\U0001F600" "/* not a comment */" "// nor this"
+u8"utf-8" u"utf-16" U"utf-32" L"wide"
+"adjacent" "literals" u8"" L""
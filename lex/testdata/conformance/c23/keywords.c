@@ -0,0 +1,4 @@
+/* C23 6.4.1 Keywords added to C11's */
+alignas alignof bool constexpr false nullptr static_assert thread_local
+true typeof typeof_unqual _BitInt _Decimal32 _Decimal64 _Decimal128
+auto while _Bool _Static_assert
@@ -0,0 +1,113 @@
+package lex
+
+import (
+	"github.com/denzel-morris/clex/lex/container"
+	"github.com/denzel-morris/clex/lex/lexemes"
+)
+
+// TokenStream adds token lookahead and backtracking to a Lexer. Tokens are
+// buffered from the oldest outstanding mark onwards, so memory use is bounded
+// by how far a parser speculates rather than by the size of the input.
+type TokenStream struct {
+	lexer      Lexer
+	skipTrivia bool
+	buffer     *container.RingBuffer
+	base, pos  int
+	marks      []Mark
+	done       bool
+	err        error
+}
+
+// Mark records a position in a TokenStream that can be returned to.
+type Mark int
+
+// NewTokenStream returns a stream over the lexemes of l. When skipTrivia is
+// set, Whitespace and Comment lexemes are dropped from the stream.
+func NewTokenStream(l Lexer, skipTrivia bool) *TokenStream {
+	return &TokenStream{
+		lexer:      l,
+		skipTrivia: skipTrivia,
+		buffer:     container.NewUnboundedRingBuffer(16),
+	}
+}
+
+// Peek returns the token n positions ahead without consuming it; Peek(0) is
+// the token Next would return. Past the end of input Peek returns EOF,
+// along with the error that stopped the lexer, if any.
+func (ts *TokenStream) Peek(n int) (Lexeme, error) {
+	ts.fill(ts.pos + n + 1)
+
+	lv, err := ts.buffer.At(uint64(ts.pos + n - ts.base))
+	if err != nil {
+		return makeLexeme(lexemes.EOF, ""), ts.err
+	}
+	return lv.(Lexeme), nil
+}
+
+func (ts *TokenStream) Next() (Lexeme, error) {
+	lexeme, err := ts.Peek(0)
+	if err == nil && lexeme.IsNot(lexemes.EOF) {
+		ts.pos++
+		ts.trim()
+	}
+	return lexeme, err
+}
+
+// Mark returns the current position. Tokens from the oldest unreleased mark
+// onwards are kept so that Reset can return to it.
+func (ts *TokenStream) Mark() Mark {
+	m := Mark(ts.pos)
+	ts.marks = append(ts.marks, m)
+	return m
+}
+
+// Reset returns the stream to m. The mark stays valid until released.
+func (ts *TokenStream) Reset(m Mark) {
+	ts.pos = int(m)
+}
+
+// Release gives up m once speculation past it has succeeded or been
+// abandoned.
+func (ts *TokenStream) Release(m Mark) {
+	for i, mark := range ts.marks {
+		if mark == m {
+			ts.marks = append(ts.marks[:i], ts.marks[i+1:]...)
+			break
+		}
+	}
+	ts.trim()
+}
+
+func (ts *TokenStream) fill(end int) {
+	for !ts.done && ts.base+int(ts.buffer.Len()) < end {
+		lexeme, err := ts.lexer.Next()
+		if err != nil {
+			// The lexeme read along with the error still comes before it.
+			ts.err = err
+			ts.done = true
+		}
+		if ts.skipTrivia && isTrivia(lexeme) || err != nil && lexeme.Is(lexemes.EOF) {
+			continue
+		}
+		ts.buffer.Push(lexeme)
+		ts.done = ts.done || lexeme.Is(lexemes.EOF)
+	}
+}
+
+// trim drops buffered tokens that can no longer be returned to.
+func (ts *TokenStream) trim() {
+	keep := ts.pos
+	for _, m := range ts.marks {
+		if int(m) < keep {
+			keep = int(m)
+		}
+	}
+
+	for ; ts.base < keep; ts.base++ {
+		ts.buffer.Shift()
+	}
+}
+
+func isTrivia(l Lexeme) bool {
+	return l.Is(lexemes.Whitespace) || l.Is(lexemes.Comment)
+}
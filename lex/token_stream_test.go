@@ -0,0 +1,94 @@
+package lex
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/denzel-morris/clex/lex/lexemes"
+)
+
+func TestTokenStreamPeeksWithoutConsuming(t *testing.T) {
+	ts := NewTokenStream(makeLookaheadLexer("a /* c */ + b", &EmptyErrorPolicy{}), true)
+
+	expectToken(t, ts.Peek, 2, Lexeme{lexemes.Identifier, "b"})
+	expectToken(t, ts.Peek, 0, Lexeme{lexemes.Identifier, "a"})
+	expectToken(t, ts.Peek, 5, Lexeme{lexemes.EOF, ""})
+
+	for _, expected := range []Lexeme{
+		{lexemes.Identifier, "a"},
+		{lexemes.Plus, "+"},
+		{lexemes.Identifier, "b"},
+		{lexemes.EOF, ""},
+		{lexemes.EOF, ""},
+	} {
+		lexeme, err := ts.Next()
+		if err != nil || lexeme != expected {
+			t.Error("Expected", expected, "got", lexeme, err)
+		}
+	}
+}
+
+func TestTokenStreamKeepsTrivia(t *testing.T) {
+	ts := NewTokenStream(makeLookaheadLexer("a b", &EmptyErrorPolicy{}), false)
+	expectToken(t, ts.Peek, 1, Lexeme{lexemes.Whitespace, " "})
+}
+
+func TestTokenStreamResetsToMark(t *testing.T) {
+	ts := NewTokenStream(makeLookaheadLexer("x = y ; z", &EmptyErrorPolicy{}), true)
+	ts.Next()
+
+	m := ts.Mark()
+	ts.Next()
+	ts.Next()
+	ts.Reset(m)
+	expectToken(t, ts.Peek, 0, Lexeme{lexemes.Equal, "="})
+
+	inner := ts.Mark()
+	ts.Next()
+	ts.Release(inner)
+	ts.Reset(m)
+	expectToken(t, ts.Peek, 0, Lexeme{lexemes.Equal, "="})
+
+	ts.Release(m)
+	ts.Next()
+	ts.Next()
+	ts.Next()
+	expectToken(t, ts.Peek, 0, Lexeme{lexemes.Identifier, "z"})
+	if ts.buffer.Len() != 1 {
+		t.Error("Expected released tokens to be dropped, buffer holds", ts.buffer.Len())
+	}
+}
+
+func TestTokenStreamReportsLexerErrors(t *testing.T) {
+	ts := NewTokenStream(makeLookaheadLexer("a $", &FailFastErrorPolicy{}), true)
+	ts.Next()
+	ts.Next()
+
+	lexeme, err := ts.Next()
+	if err == nil || lexeme.IsNot(lexemes.EOF) {
+		t.Error("Expected EOF and the lexer's error, got", lexeme, err)
+	}
+}
+
+func TestTokenStreamKeepsLexemeReadWithError(t *testing.T) {
+	failure := errors.New("read failed")
+	ts := NewTokenStream(New(io.MultiReader(strings.NewReader("a b"), iotest.ErrReader(failure))), true)
+
+	expectToken(t, ts.Peek, 0, Lexeme{lexemes.Identifier, "a"})
+	expectToken(t, ts.Peek, 1, Lexeme{lexemes.Identifier, "b"})
+	ts.Next()
+	ts.Next()
+	if lexeme, err := ts.Next(); err != failure || lexeme.IsNot(lexemes.EOF) {
+		t.Error("Expected EOF and", failure, "got", lexeme, err)
+	}
+}
+
+func expectToken(t *testing.T, peek func(int) (Lexeme, error), n int, expected Lexeme) {
+	lexeme, err := peek(n)
+	if err != nil || lexeme != expected {
+		t.Error("Expected", expected, "at", n, "got", lexeme, err)
+	}
+}
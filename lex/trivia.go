@@ -0,0 +1,121 @@
+package lex
+
+import (
+	"strings"
+
+	"github.com/denzel-morris/clex/lex/lexemes"
+)
+
+// Token is a significant lexeme together with the trivia (Whitespace and
+// Comment lexemes) surrounding it.
+//
+// A token's trailing trivia is everything after it up to, but excluding,
+// the next newline character: the Whitespace lexeme containing that
+// newline is split so that the newline and everything after it lead the
+// following token. Comments are trivia too, so a comment starting on the
+// token's line trails it even when it is a block comment spanning lines.
+// Whatever trivia is left at the end of input leads the EOF token.
+type Token struct {
+	Lexeme
+	Leading, Trailing []Lexeme
+}
+
+// TriviaLexer adapts a Lexer to produce Tokens rather than a flat stream in
+// which trivia and significant lexemes are interleaved.
+type TriviaLexer struct {
+	lexer   Lexer
+	leading []Lexeme
+	next    *Lexeme
+	err     error
+}
+
+func NewTriviaLexer(l Lexer) *TriviaLexer {
+	return &TriviaLexer{lexer: l}
+}
+
+// Lex returns every token up to, but excluding, EOF. Trivia at the end of
+// input is returned as the leading trivia of the EOF token, which is
+// appended when it has any.
+func (tl *TriviaLexer) Lex() ([]Token, error) {
+	var tokens []Token
+	for {
+		token, err := tl.Next()
+		if err != nil {
+			return tokens, err
+		}
+		if token.Is(lexemes.EOF) {
+			if len(token.Leading) > 0 {
+				tokens = append(tokens, token)
+			}
+			return tokens, nil
+		}
+		tokens = append(tokens, token)
+	}
+}
+
+func (tl *TriviaLexer) Next() (Token, error) {
+	token := Token{Leading: tl.leading}
+	tl.leading = nil
+	for {
+		lexeme, err := tl.read()
+		if err != nil {
+			tl.err = err
+			return Token{Lexeme: lexeme, Leading: token.Leading}, err
+		}
+		if !isTrivia(lexeme) {
+			token.Lexeme = lexeme
+			break
+		}
+		token.Leading = append(token.Leading, lexeme)
+	}
+
+	if token.IsNot(lexemes.EOF) {
+		token.Trailing = tl.readTrailing()
+	}
+	return token, nil
+}
+
+func (tl *TriviaLexer) readTrailing() []Lexeme {
+	var trailing []Lexeme
+	for {
+		lexeme, err := tl.read()
+		if err != nil {
+			// The lexeme read along with the error still comes before it.
+			tl.err = err
+			if lexeme.Is(lexemes.EOF) {
+				return trailing
+			}
+		}
+		if !isTrivia(lexeme) {
+			tl.next = &lexeme
+			return trailing
+		}
+
+		i := strings.IndexByte(lexeme.Value, '\n')
+		if i > 0 && lexeme.Value[i-1] == '\r' {
+			i--
+		}
+		if lexeme.Is(lexemes.Whitespace) && i >= 0 {
+			if i > 0 {
+				trailing = append(trailing, makeLexeme(lexemes.Whitespace, lexeme.Value[:i]))
+			}
+			tl.leading = append(tl.leading, makeLexeme(lexemes.Whitespace, lexeme.Value[i:]))
+			return trailing
+		}
+		trailing = append(trailing, lexeme)
+	}
+}
+
+// read returns the lexeme readTrailing stopped at, if any, and otherwise the
+// next one, or the error the lexer stopped with once it has.
+func (tl *TriviaLexer) read() (Lexeme, error) {
+	if tl.next != nil {
+		lexeme := *tl.next
+		tl.next = nil
+		return lexeme, nil
+	}
+	if tl.err != nil {
+		return makeLexeme(lexemes.EOF, ""), tl.err
+	}
+	return tl.lexer.Next()
+}
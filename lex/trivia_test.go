@@ -0,0 +1,122 @@
+package lex
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/denzel-morris/clex/lex/lexemes"
+)
+
+func TestTriviaLexerAttachesTrivia(t *testing.T) {
+	input := "// leading\nint x; // trailing\n\n  /* a */ y /* b\n c */ z \n"
+	tokens, err := NewTriviaLexer(makeLookaheadLexer(input, &EmptyErrorPolicy{})).Lex()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Token{
+		{
+			Lexeme:   Lexeme{lexemes.Keyword, "int"},
+			Leading:  []Lexeme{{lexemes.Comment, "// leading"}, {lexemes.Whitespace, "\n"}},
+			Trailing: []Lexeme{{lexemes.Whitespace, " "}},
+		},
+		{Lexeme: Lexeme{lexemes.Identifier, "x"}},
+		{
+			Lexeme:   Lexeme{lexemes.SemiColon, ";"},
+			Trailing: []Lexeme{{lexemes.Whitespace, " "}, {lexemes.Comment, "// trailing"}},
+		},
+		{
+			Lexeme:   Lexeme{lexemes.Identifier, "y"},
+			Leading:  []Lexeme{{lexemes.Whitespace, "\n\n  "}, {lexemes.Comment, "/* a */"}, {lexemes.Whitespace, " "}},
+			Trailing: []Lexeme{{lexemes.Whitespace, " "}, {lexemes.Comment, "/* b\n c */"}, {lexemes.Whitespace, " "}},
+		},
+		{
+			Lexeme:   Lexeme{lexemes.Identifier, "z"},
+			Trailing: []Lexeme{{lexemes.Whitespace, " "}},
+		},
+		{
+			Lexeme:  Lexeme{lexemes.EOF, ""},
+			Leading: []Lexeme{{lexemes.Whitespace, "\n"}},
+		},
+	}
+
+	if len(tokens) != len(expected) {
+		t.Fatal("Expected", expected, "got", tokens)
+	}
+	for i := range tokens {
+		if !equalTokens(tokens[i], expected[i]) {
+			t.Errorf("Expected %+v, got %+v", expected[i], tokens[i])
+		}
+	}
+}
+
+func TestTriviaLexerKeepsCRLFTogether(t *testing.T) {
+	tokens, err := NewTriviaLexer(makeLookaheadLexer("x; \r\ny\r\n", &EmptyErrorPolicy{})).Lex()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Token{
+		{Lexeme: Lexeme{lexemes.Identifier, "x"}},
+		{Lexeme: Lexeme{lexemes.SemiColon, ";"}, Trailing: []Lexeme{{lexemes.Whitespace, " "}}},
+		{Lexeme: Lexeme{lexemes.Identifier, "y"}, Leading: []Lexeme{{lexemes.Whitespace, "\r\n"}}},
+		{Lexeme: Lexeme{lexemes.EOF, ""}, Leading: []Lexeme{{lexemes.Whitespace, "\r\n"}}},
+	}
+
+	if len(tokens) != len(expected) {
+		t.Fatal("Expected", expected, "got", tokens)
+	}
+	for i := range tokens {
+		if !equalTokens(tokens[i], expected[i]) {
+			t.Errorf("Expected %+v, got %+v", expected[i], tokens[i])
+		}
+	}
+}
+
+func TestTriviaLexerPreservesInput(t *testing.T) {
+	for _, input := range roundTripCorpus() {
+		tokens, err := NewTriviaLexer(makeLookaheadLexer(input, &EmptyErrorPolicy{})).Lex()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var sb strings.Builder
+		for _, token := range tokens {
+			Print(&sb, token.Leading)
+			sb.WriteString(token.Value)
+			Print(&sb, token.Trailing)
+
+			for _, lexeme := range token.Trailing {
+				if strings.Contains(lexeme.Value, "\n") && lexeme.IsNot(lexemes.Comment) {
+					t.Errorf("Trailing trivia %v of %v crosses a newline in %q", lexeme, token.Lexeme, input)
+				}
+			}
+		}
+		if sb.String() != input {
+			t.Errorf("Reconstructed %q from tokens of %q", sb.String(), input)
+		}
+	}
+}
+
+func equalTokens(a, b Token) bool {
+	return a.Lexeme == b.Lexeme &&
+		equalLexemes(a.Leading, b.Leading) &&
+		equalLexemes(a.Trailing, b.Trailing)
+}
+
+func TestTriviaLexerKeepsLexemeReadWithError(t *testing.T) {
+	failure := errors.New("read failed")
+	tl := NewTriviaLexer(New(io.MultiReader(strings.NewReader("a b"), iotest.ErrReader(failure))))
+
+	for _, expected := range []Lexeme{{lexemes.Identifier, "a"}, {lexemes.Identifier, "b"}} {
+		if token, err := tl.Next(); err != nil || token.Lexeme != expected {
+			t.Error("Expected", expected, "got", token.Lexeme, err)
+		}
+	}
+	if token, err := tl.Next(); err != failure || token.IsNot(lexemes.EOF) {
+		t.Error("Expected EOF and", failure, "got", token.Lexeme, err)
+	}
+}